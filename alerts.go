@@ -0,0 +1,185 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AlertOpts sets the thresholds of the rules generated by AlertingRules. Zero
+// values select the defaults.
+type AlertOpts struct {
+	// GroupName is the name of the rule group. Defaults to "casbin".
+	GroupName string
+	// Window is the range over which rates are computed. Defaults to 5m.
+	Window time.Duration
+	// For is how long a condition must hold before an alert fires.
+	// Defaults to 5m.
+	For time.Duration
+	// Severity is the value of the severity label of the alerts. Defaults
+	// to "warning".
+	Severity string
+
+	// DenialRatio is the ratio of denied to all enforce requests above
+	// which CasbinHighDenialRate fires. Defaults to 0.5.
+	DenialRatio float64
+	// LatencyP99 is the 99th percentile enforce duration above which
+	// CasbinEnforceLatencyHigh fires. Defaults to 250ms.
+	LatencyP99 time.Duration
+	// AdapterErrors is the number of adapter errors reported through
+	// LogError within the window above which CasbinAdapterErrors fires.
+	// Defaults to 5.
+	AdapterErrors int
+	// StaleAfter is how long without enforce requests before
+	// CasbinEnforcerStale fires. Defaults to 15m.
+	StaleAfter time.Duration
+}
+
+// withDefaults returns a copy of the options with zero values replaced by
+// the defaults.
+func (o AlertOpts) withDefaults() AlertOpts {
+	if o.GroupName == "" {
+		o.GroupName = "casbin"
+	}
+	if o.Window <= 0 {
+		o.Window = 5 * time.Minute
+	}
+	if o.For <= 0 {
+		o.For = 5 * time.Minute
+	}
+	if o.Severity == "" {
+		o.Severity = "warning"
+	}
+	if o.DenialRatio <= 0 {
+		o.DenialRatio = 0.5
+	}
+	if o.LatencyP99 <= 0 {
+		o.LatencyP99 = 250 * time.Millisecond
+	}
+	if o.AdapterErrors <= 0 {
+		o.AdapterErrors = 5
+	}
+	if o.StaleAfter <= 0 {
+		o.StaleAfter = 15 * time.Minute
+	}
+	return o
+}
+
+// alertRule is a Prometheus alerting rule.
+type alertRule struct {
+	name    string
+	expr    string
+	summary string
+}
+
+// AlertingRules returns a Prometheus rule file with alerts for common SLOs
+// of the enforcer: a high denial rate, a 99th percentile enforce latency
+// breach, adapter error spikes, and an enforcer that stopped receiving
+// requests. The expressions reference the metric names the logger was
+// configured with, including the namespace, duration unit and strict
+// naming. Rules whose metrics or labels are disabled are left out, as is the
+// latency rule if CountOnlyDuration leaves no buckets to compute a quantile
+// from.
+func (p *PrometheusLogger) AlertingRules(opts AlertOpts) string {
+	opts = opts.withDefaults()
+	window := promDuration(opts.Window)
+
+	var rules []alertRule
+	if p.enforceTotal != nil {
+		enforceTotal := p.metricName("casbin_enforce_total", counterMetric)
+		denied := ""
+		switch {
+		case slices.Contains(p.enforceLabels, LabelAllowed):
+			denied = `allowed="false"`
+		case slices.Contains(p.enforceLabels, LabelOutcome):
+			denied = fmt.Sprintf(`outcome=%q`, OutcomeDeny)
+		}
+		if denied != "" {
+			rules = append(rules, alertRule{
+				name: "CasbinHighDenialRate",
+				expr: fmt.Sprintf("sum(rate(%s{%s}[%s])) / sum(rate(%s[%s])) > %s",
+					enforceTotal, denied, window, enforceTotal, window, formatFloat(opts.DenialRatio)),
+				summary: fmt.Sprintf("More than %s%% of casbin enforce requests are denied", formatFloat(opts.DenialRatio*100)),
+			})
+		}
+	}
+	if p.enforceDuration != nil && !p.countOnlyDuration {
+		rules = append(rules, alertRule{
+			name: "CasbinEnforceLatencyHigh",
+			expr: fmt.Sprintf("histogram_quantile(0.99, sum by (le) (rate(%s_bucket[%s]))) > %s",
+				p.metricName("casbin_enforce_duration_seconds", histogramMetric), window, formatFloat(p.durationValue(opts.LatencyP99))),
+			summary: fmt.Sprintf("The 99th percentile casbin enforce latency is above %v", opts.LatencyP99),
+		})
+	}
+	if p.internalErrors != nil {
+		rules = append(rules, alertRule{
+			name: "CasbinAdapterErrors",
+			expr: fmt.Sprintf(`sum(increase(%s{category="adapter"}[%s])) > %d`,
+				p.metricName("casbin_internal_errors_total", counterMetric), window, opts.AdapterErrors),
+			summary: fmt.Sprintf("More than %d casbin adapter errors within %s", opts.AdapterErrors, window),
+		})
+	}
+	if p.enforceTotal != nil {
+		enforceTotal := p.metricName("casbin_enforce_total", counterMetric)
+		stale := promDuration(opts.StaleAfter)
+		rules = append(rules, alertRule{
+			name:    "CasbinEnforcerStale",
+			expr:    fmt.Sprintf("sum(increase(%s[%s])) == 0 or absent(%s)", enforceTotal, stale, enforceTotal),
+			summary: fmt.Sprintf("The casbin enforcer received no requests within %s", stale),
+		})
+	}
+
+	var b strings.Builder
+	b.WriteString("groups:\n")
+	fmt.Fprintf(&b, "  - name: %s\n", strconv.Quote(opts.GroupName))
+	b.WriteString("    rules:\n")
+	for _, rule := range rules {
+		fmt.Fprintf(&b, "      - alert: %s\n", rule.name)
+		fmt.Fprintf(&b, "        expr: %s\n", strconv.Quote(rule.expr))
+		fmt.Fprintf(&b, "        for: %s\n", promDuration(opts.For))
+		b.WriteString("        labels:\n")
+		fmt.Fprintf(&b, "          severity: %s\n", strconv.Quote(opts.Severity))
+		b.WriteString("        annotations:\n")
+		fmt.Fprintf(&b, "          summary: %s\n", strconv.Quote(rule.summary))
+	}
+	return b.String()
+}
+
+// promDuration formats d as a Prometheus duration such as "5m", in the
+// largest unit that represents it exactly.
+func promDuration(d time.Duration) string {
+	for _, unit := range []struct {
+		suffix   string
+		duration time.Duration
+	}{
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	} {
+		if d%unit.duration == 0 {
+			return strconv.FormatInt(int64(d/unit.duration), 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+// formatFloat formats f without an exponent or trailing zeros.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
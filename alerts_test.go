@@ -0,0 +1,142 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.yaml.in/yaml/v2"
+)
+
+// ruleFile is the part of a Prometheus rule file checked by the tests.
+type ruleFile struct {
+	Groups []struct {
+		Name  string `yaml:"name"`
+		Rules []struct {
+			Alert       string            `yaml:"alert"`
+			Expr        string            `yaml:"expr"`
+			For         string            `yaml:"for"`
+			Labels      map[string]string `yaml:"labels"`
+			Annotations map[string]string `yaml:"annotations"`
+		} `yaml:"rules"`
+	} `yaml:"groups"`
+}
+
+func TestAlertingRules(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		Namespace:    "myapp",
+		DurationUnit: Milliseconds,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	rules := logger.AlertingRules(AlertOpts{
+		GroupName:  "authz",
+		For:        10 * time.Minute,
+		Severity:   "page",
+		LatencyP99: 50 * time.Millisecond,
+	})
+
+	var file ruleFile
+	if err := yaml.UnmarshalStrict([]byte(rules), &file); err != nil {
+		t.Fatalf("Generated rules do not parse: %v\n%s", err, rules)
+	}
+	if len(file.Groups) != 1 || file.Groups[0].Name != "authz" {
+		t.Fatalf("Expected one group named authz, got %+v", file.Groups)
+	}
+
+	expected := map[string]string{
+		"CasbinHighDenialRate":     `sum(rate(myapp_enforce_total{allowed="false"}[5m])) / sum(rate(myapp_enforce_total[5m])) > 0.5`,
+		"CasbinEnforceLatencyHigh": `histogram_quantile(0.99, sum by (le) (rate(myapp_enforce_duration_milliseconds_bucket[5m]))) > 50`,
+		"CasbinAdapterErrors":      `sum(increase(myapp_internal_errors_total{category="adapter"}[5m])) > 5`,
+		"CasbinEnforcerStale":      `sum(increase(myapp_enforce_total[15m])) == 0 or absent(myapp_enforce_total)`,
+	}
+	rulesByName := file.Groups[0].Rules
+	if len(rulesByName) != len(expected) {
+		t.Errorf("Expected %d rules, got %d", len(expected), len(rulesByName))
+	}
+	for _, rule := range rulesByName {
+		if expr, ok := expected[rule.Alert]; !ok || rule.Expr != expr {
+			t.Errorf("Expected %s to have expression %q, got %q", rule.Alert, expr, rule.Expr)
+		}
+		if rule.For != "10m" {
+			t.Errorf("Expected %s to fire after 10m, got %q", rule.Alert, rule.For)
+		}
+		if rule.Labels["severity"] != "page" {
+			t.Errorf("Expected %s to have severity page, got %q", rule.Alert, rule.Labels["severity"])
+		}
+		if strings.Contains(rule.Expr, "casbin_") {
+			t.Errorf("Expected %s to use the myapp namespace, got %q", rule.Alert, rule.Expr)
+		}
+	}
+}
+
+func TestAlertingRules_DisabledMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels:  []string{LabelDomain},
+		EnabledMetrics: []string{"enforce_total"},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	var file ruleFile
+	if err := yaml.UnmarshalStrict([]byte(logger.AlertingRules(AlertOpts{})), &file); err != nil {
+		t.Fatalf("Generated rules do not parse: %v", err)
+	}
+	// Without the allowed or outcome label no denial rate can be computed,
+	// so only the stale enforcer rule remains.
+	if rules := file.Groups[0].Rules; len(rules) != 1 || rules[0].Alert != "CasbinEnforcerStale" {
+		t.Errorf("Expected only CasbinEnforcerStale, got %+v", rules)
+	}
+}
+
+func TestAlertingRules_CountOnlyDuration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		CountOnlyDuration: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	var file ruleFile
+	if err := yaml.UnmarshalStrict([]byte(logger.AlertingRules(AlertOpts{})), &file); err != nil {
+		t.Fatalf("Generated rules do not parse: %v", err)
+	}
+	// Only the +Inf bucket is recorded, so no quantile can be computed.
+	for _, rule := range file.Groups[0].Rules {
+		if rule.Alert == "CasbinEnforceLatencyHigh" {
+			t.Errorf("Expected no latency rule with CountOnlyDuration, got %+v", rule)
+		}
+	}
+	if rules := file.Groups[0].Rules; len(rules) != 3 {
+		t.Errorf("Expected the other 3 rules, got %+v", rules)
+	}
+}
+
+func TestPromDuration(t *testing.T) {
+	testCases := map[time.Duration]string{
+		2 * time.Hour:           "2h",
+		90 * time.Minute:        "90m",
+		30 * time.Second:        "30s",
+		1500 * time.Millisecond: "1500ms",
+	}
+	for d, expected := range testCases {
+		if got := promDuration(d); got != expected {
+			t.Errorf("promDuration(%v) = %q, expected %q", d, got, expected)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// allowRatioCollector is a collector of casbin_enforce_allow_ratio, the ratio
+// of allowed enforce requests to all enforce requests by domain, computed at
+// scrape time from the counts it keeps.
+type allowRatioCollector struct {
+	desc *prometheus.Desc
+
+	mu     sync.Mutex
+	counts map[string]*allowCounts
+}
+
+// allowCounts are the enforce request counts of a domain.
+type allowCounts struct {
+	allowed, total uint64
+}
+
+// newAllowRatioCollector creates an allow ratio collector.
+func newAllowRatioCollector(options *PrometheusLoggerOptions) *allowRatioCollector {
+	return &allowRatioCollector{
+		desc: prometheus.NewDesc(
+			options.metricName("casbin_enforce_allow_ratio", gaugeMetric),
+			options.metricHelp("casbin_enforce_allow_ratio", "Ratio of allowed enforce requests to all enforce requests by domain"),
+			[]string{"domain"}, nil,
+		),
+		counts: make(map[string]*allowCounts),
+	}
+}
+
+// observe counts an enforce request of domain.
+func (c *allowRatioCollector) observe(domain string, allowed bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts, ok := c.counts[domain]
+	if !ok {
+		counts = &allowCounts{}
+		c.counts[domain] = counts
+	}
+	counts.total++
+	if allowed {
+		counts.allowed++
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *allowRatioCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector. Domains without requests are
+// omitted rather than reported as NaN.
+func (c *allowRatioCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for domain, counts := range c.counts {
+		if counts.total == 0 {
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts.allowed)/float64(counts.total), domain)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestEnforceAllowRatio(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{EnforceAllowRatio: true})
+	defer logger.UnregisterFrom(registry)
+
+	for domain, decisions := range map[string][]bool{
+		"domain1": {true, true, true, false},
+		"domain2": {false, false},
+		"":        {true},
+	} {
+		for _, allowed := range decisions {
+			logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: domain, Allowed: allowed})
+		}
+	}
+
+	expected := `
+# HELP casbin_enforce_allow_ratio Ratio of allowed enforce requests to all enforce requests by domain
+# TYPE casbin_enforce_allow_ratio gauge
+casbin_enforce_allow_ratio{domain="default"} 1
+casbin_enforce_allow_ratio{domain="domain1"} 0.75
+casbin_enforce_allow_ratio{domain="domain2"} 0
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_allow_ratio"); err != nil {
+		t.Error(err)
+	}
+
+	if logger := NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), nil); logger.GetEnforceAllowRatio() != nil {
+		t.Error("Expected no enforce allow ratio collector by default")
+	}
+}
+
+func TestEnforceAllowRatio_NoRequests(t *testing.T) {
+	logger := NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), &PrometheusLoggerOptions{EnforceAllowRatio: true})
+	if count := testutil.CollectAndCount(logger.GetEnforceAllowRatio()); count != 0 {
+		t.Errorf("Expected no allow ratio series without requests, got %d", count)
+	}
+}
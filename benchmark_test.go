@@ -0,0 +1,114 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Run with: go test -run '^$' -bench . -benchmem
+
+func BenchmarkRecordEnforce(b *testing.B) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	entry := &LogEntry{
+		EventType: EventEnforce,
+		Subject:   "alice",
+		Object:    "data1",
+		Action:    "read",
+		Domain:    "domain1",
+		Allowed:   true,
+		Duration:  time.Millisecond,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.recordEnforceMetrics(context.Background(), entry)
+	}
+}
+
+// BenchmarkRecordEnforceGeneralPath records the default labels through the
+// general path, for comparison with BenchmarkRecordEnforce.
+func BenchmarkRecordEnforceGeneralPath(b *testing.B) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+	logger.defaultLabelsFastPath = false
+
+	entry := &LogEntry{
+		EventType: EventEnforce,
+		Subject:   "alice",
+		Object:    "data1",
+		Action:    "read",
+		Domain:    "domain1",
+		Allowed:   true,
+		Duration:  time.Millisecond,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.recordEnforceMetrics(context.Background(), entry)
+	}
+}
+
+func BenchmarkRecordEnforceCustomLabels(b *testing.B) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelDomain, LabelSubject, LabelObject, LabelAction},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	entry := &LogEntry{
+		EventType: EventEnforce,
+		Subject:   "alice",
+		Object:    "data1",
+		Action:    "read",
+		Domain:    "domain1",
+		Allowed:   true,
+		Duration:  time.Millisecond,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.recordEnforceMetrics(context.Background(), entry)
+	}
+}
+
+func BenchmarkRecordPolicy(b *testing.B) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	entry := &LogEntry{
+		EventType: EventAddPolicy,
+		RuleCount: 5,
+		Duration:  time.Millisecond,
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.recordPolicyMetrics(entry)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrCallbackPanic is wrapped by the error OnAfterEvent returns when the log
+// callback panicked and RecoverCallbackPanics is set.
+var ErrCallbackPanic = errors.New("log callback panicked")
+
+// ErrCallbackTimeout is wrapped by the error OnAfterEvent returns when the
+// log callback did not return within CallbackTimeout.
+var ErrCallbackTimeout = errors.New("log callback timed out")
+
+// NewChannelCallback returns a log callback that forwards each entry to ch,
+// for in-process consumers such as a live tail of authorization decisions.
+//
+// The callback sends a copy of the entry, because the caller may reuse the
+// original once OnAfterEvent returns. The copy shares the Rules slice, which
+// consumers must not modify.
+//
+// If nonBlocking is true and ch is full, the entry is dropped and the callback
+// returns nil. Use NewChannelCallbackWithDrops to count the dropped entries.
+// Otherwise the callback blocks until ch accepts the entry.
+func NewChannelCallback(ch chan<- *LogEntry, nonBlocking bool) func(entry *LogEntry) error {
+	callback, _ := NewChannelCallbackWithDrops(ch, nonBlocking)
+	return callback
+}
+
+// NewChannelCallbackWithDrops is like NewChannelCallback, and additionally
+// returns a function that reports the number of entries dropped so far
+// because ch was full.
+func NewChannelCallbackWithDrops(ch chan<- *LogEntry, nonBlocking bool) (callback func(entry *LogEntry) error, dropped func() uint64) {
+	var drops atomic.Uint64
+	callback = func(entry *LogEntry) error {
+		clone := *entry
+		if !nonBlocking {
+			ch <- &clone
+			return nil
+		}
+
+		select {
+		case ch <- &clone:
+		default:
+			drops.Add(1)
+		}
+		return nil
+	}
+	return callback, drops.Load
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewChannelCallback(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	ch := make(chan *LogEntry, 2)
+	callback, dropped := NewChannelCallbackWithDrops(ch, true)
+	logger.SetLogCallback(callback)
+
+	subjects := []string{"alice", "bob"}
+	for _, subject := range subjects {
+		entry := &LogEntry{
+			IsActive:  true,
+			EventType: EventEnforce,
+			StartTime: time.Now(),
+			Subject:   subject,
+		}
+		if err := logger.OnAfterEvent(entry); err != nil {
+			t.Errorf("OnAfterEvent returned error: %v", err)
+		}
+		// Reusing the entry must not affect the forwarded copy
+		entry.Subject = "reused"
+	}
+
+	// The channel is full, so the next entry is dropped
+	err := logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		StartTime: time.Now(),
+		Subject:   "charlie",
+	})
+	if err != nil {
+		t.Errorf("Expected a dropped entry to return nil, got %v", err)
+	}
+	if got := dropped(); got != 1 {
+		t.Errorf("Expected 1 dropped entry, got %d", got)
+	}
+
+	close(ch)
+	var received []string
+	for entry := range ch {
+		received = append(received, entry.Subject)
+	}
+	if len(received) != len(subjects) {
+		t.Fatalf("Expected %d entries, got %v", len(subjects), received)
+	}
+	for i, subject := range subjects {
+		if received[i] != subject {
+			t.Errorf("Expected entry %d to have subject %s, got %s", i, subject, received[i])
+		}
+	}
+}
+
+func TestNewChannelCallback_Blocking(t *testing.T) {
+	ch := make(chan *LogEntry)
+	callback := NewChannelCallback(ch, false)
+
+	go func() {
+		callback(&LogEntry{EventType: EventAddPolicy, RuleCount: 3})
+	}()
+
+	select {
+	case entry := <-ch:
+		if entry.RuleCount != 3 {
+			t.Errorf("Expected RuleCount 3, got %d", entry.RuleCount)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for entry")
+	}
+}
+
+func TestRecoverCallbackPanics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		RecoverCallbackPanics: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	logger.SetLogCallback(func(entry *LogEntry) error {
+		panic("callback failure")
+	})
+
+	err := logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		StartTime: time.Now(),
+		Allowed:   true,
+	})
+	if !errors.Is(err, ErrCallbackPanic) {
+		t.Errorf("Expected ErrCallbackPanic, got %v", err)
+	}
+
+	if value := testutil.ToFloat64(logger.GetCallbackPanics()); value != 1 {
+		t.Errorf("Expected 1 recovered panic, got %v", value)
+	}
+	if value := testutil.ToFloat64(logger.enforceTotal.WithLabelValues("true", "default")); value != 1 {
+		t.Errorf("Expected metrics to be recorded before the callback, got %v", value)
+	}
+}
+
+func TestRecoverCallbackPanics_Disabled(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.SetLogCallback(func(entry *LogEntry) error {
+		panic("callback failure")
+	})
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected the panic to propagate by default")
+		}
+	}()
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now()})
+}
+
+func TestCallbackDuration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{CallbackDuration: true})
+	defer logger.UnregisterFrom(registry)
+
+	const sleep = 20 * time.Millisecond
+	logger.SetLogCallback(func(entry *LogEntry) error {
+		time.Sleep(sleep)
+		return nil
+	})
+	for i := 0; i < 2; i++ {
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now()})
+	}
+
+	families, err := gatherCollectors(logger.GetCallbackDuration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	histogram := families[0].GetMetric()[0].GetHistogram()
+	if histogram.GetSampleCount() != 2 {
+		t.Errorf("Expected 2 callback durations, got %d", histogram.GetSampleCount())
+	}
+	if sum := histogram.GetSampleSum(); sum < 2*sleep.Seconds() || sum > 2 {
+		t.Errorf("Expected the callback durations to sum to at least %v, got %vs", 2*sleep, sum)
+	}
+}
+
+func TestCallbackTimeout(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		CallbackTimeout: 20 * time.Millisecond,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	release := make(chan struct{})
+	defer close(release)
+	logger.SetLogCallback(func(entry *LogEntry) error {
+		if entry.Subject == "slow" {
+			<-release
+		}
+		return nil
+	})
+
+	start := time.Now()
+	err := logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), Subject: "slow"})
+	if !errors.Is(err, ErrCallbackTimeout) {
+		t.Errorf("Expected ErrCallbackTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected OnAfterEvent to return after the timeout, took %v", elapsed)
+	}
+	if value := testutil.ToFloat64(logger.GetCallbackTimeouts()); value != 1 {
+		t.Errorf("Expected 1 callback timeout, got %v", value)
+	}
+
+	if err := logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), Subject: "fast"}); err != nil {
+		t.Errorf("Expected no error from a fast callback, got %v", err)
+	}
+	if value := testutil.ToFloat64(logger.GetCallbackTimeouts()); value != 1 {
+		t.Errorf("Expected the timeout count to stay at 1, got %v", value)
+	}
+}
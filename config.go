@@ -0,0 +1,135 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LoggerConfig is the serializable configuration of a PrometheusLogger, for
+// loading it from a JSON or YAML file with NewPrometheusLoggerFromConfig.
+type LoggerConfig struct {
+	// Namespace replaces the "casbin" prefix of the metric names.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// EnforceLabels are the labels of the enforce metrics, such as "allowed"
+	// and "domain". Defaults to DefaultEnforceLabels.
+	EnforceLabels []string `json:"enforce_labels,omitempty" yaml:"enforce_labels,omitempty"`
+	// DurationBuckets are the buckets of the enforce duration histogram in
+	// seconds. Defaults to prometheus.DefBuckets.
+	DurationBuckets []float64 `json:"duration_buckets,omitempty" yaml:"duration_buckets,omitempty"`
+	// DurationType is the type of the duration metrics. Only "histogram",
+	// the default, is supported.
+	DurationType string `json:"duration_type,omitempty" yaml:"duration_type,omitempty"`
+	// DurationUnit is "seconds", the default, or "milliseconds".
+	DurationUnit string `json:"duration_unit,omitempty" yaml:"duration_unit,omitempty"`
+	// EventTypes are the names of the event types to log, such as "enforce"
+	// or "addPolicy". Empty logs every event type.
+	EventTypes []string `json:"event_types,omitempty" yaml:"event_types,omitempty"`
+	// DisabledMetrics are the metrics not to create, named like
+	// EnabledMetrics, such as "enforce_duration_seconds".
+	DisabledMetrics []string `json:"disabled_metrics,omitempty" yaml:"disabled_metrics,omitempty"`
+	// MaxSeries caps the number of distinct enforce label combinations.
+	MaxSeries int `json:"max_series,omitempty" yaml:"max_series,omitempty"`
+	// StrictNaming adjusts metric names to the Prometheus naming conventions.
+	StrictNaming bool `json:"strict_naming,omitempty" yaml:"strict_naming,omitempty"`
+}
+
+// options returns the logger options described by the config.
+func (c *LoggerConfig) options() (*PrometheusLoggerOptions, error) {
+	if c.DurationType != "" && c.DurationType != "histogram" {
+		return nil, fmt.Errorf("unsupported duration type %q", c.DurationType)
+	}
+
+	options := &PrometheusLoggerOptions{
+		Namespace:              c.Namespace,
+		EnforceLabels:          c.EnforceLabels,
+		EnforceDurationBuckets: c.DurationBuckets,
+		DurationUnit:           DurationUnit(c.DurationUnit),
+		MaxSeries:              c.MaxSeries,
+		StrictNaming:           c.StrictNaming,
+	}
+	if len(c.DisabledMetrics) > 0 {
+		// The names do not depend on the metrics, so those of an empty
+		// logger suffice.
+		names := slices.Sorted(maps.Keys((&PrometheusLogger{}).namedMetricFields()))
+		for _, name := range c.DisabledMetrics {
+			if !slices.Contains(names, name) {
+				return nil, fmt.Errorf("unknown metric %q in disabled metrics", name)
+			}
+		}
+		options.EnabledMetrics = slices.DeleteFunc(names, func(name string) bool {
+			return slices.Contains(c.DisabledMetrics, name)
+		})
+	}
+	return options, nil
+}
+
+// eventTypes returns the event types named by the config, or nil if it
+// names none.
+func (c *LoggerConfig) eventTypes() ([]EventType, error) {
+	var eventTypes []EventType
+	for _, name := range c.EventTypes {
+		eventType, err := ParseEventType(name)
+		if err != nil {
+			return nil, err
+		}
+		eventTypes = append(eventTypes, eventType)
+	}
+	return eventTypes, nil
+}
+
+// NewPrometheusLoggerFromConfig creates a new PrometheusLogger configured by
+// cfg, registered with registry. If registry is nil, the metrics are
+// registered with the default Prometheus registry, adopting metrics already
+// registered there by another logger. Unlike NewPrometheusLoggerWithOptions,
+// an invalid configuration or a registration failure is returned as an
+// error.
+func NewPrometheusLoggerFromConfig(registry *prometheus.Registry, cfg LoggerConfig) (*PrometheusLogger, error) {
+	options, err := cfg.options()
+	if err != nil {
+		return nil, fmt.Errorf("invalid logger config: %w", err)
+	}
+	eventTypes, err := cfg.eventTypes()
+	if err != nil {
+		return nil, fmt.Errorf("invalid logger config: %w", err)
+	}
+	logger, err := newPrometheusLogger(options)
+	if err != nil {
+		return nil, fmt.Errorf("invalid logger config: %w", err)
+	}
+	if eventTypes != nil {
+		if err := logger.SetEventTypes(eventTypes); err != nil {
+			logger.Close()
+			return nil, err
+		}
+	}
+
+	if registry == nil {
+		err = logger.registerAdopting(prometheus.DefaultRegisterer)
+		logger.gatherer = prometheus.DefaultGatherer
+	} else {
+		err = logger.register(registry)
+		logger.gatherer = registry
+	}
+	if err != nil {
+		logger.Close()
+		return nil, fmt.Errorf("registering metrics: %w", err)
+	}
+	return logger, nil
+}
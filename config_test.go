@@ -0,0 +1,129 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewPrometheusLoggerFromConfig(t *testing.T) {
+	data := []byte(`{
+		"namespace": "myapp",
+		"enforce_labels": ["domain", "allowed"],
+		"duration_buckets": [0.01, 0.1, 1],
+		"duration_type": "histogram",
+		"event_types": ["enforce", "addPolicy"],
+		"disabled_metrics": ["role_closure_size", "policy_state_count"],
+		"max_series": 100
+	}`)
+	var cfg LoggerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+
+	registry := prometheus.NewRegistry()
+	logger, err := NewPrometheusLoggerFromConfig(registry, cfg)
+	if err != nil {
+		t.Fatalf("NewPrometheusLoggerFromConfig returned error: %v", err)
+	}
+	defer logger.UnregisterFrom(registry)
+
+	if !slices.Equal(logger.enforceLabels, []string{LabelAllowed, LabelDomain}) {
+		t.Errorf("Expected enforce labels [allowed domain], got %v", logger.enforceLabels)
+	}
+	if logger.enforceSeriesOverflow == nil {
+		t.Error("Expected max series to enable the series overflow counter")
+	}
+	if !logger.isEventTypeEnabled(EventAddPolicy) || logger.isEventTypeEnabled(EventRemovePolicy) {
+		t.Errorf("Expected only enforce and addPolicy events to be enabled, got %v", logger.enabledEventTypes)
+	}
+	if logger.roleClosureSize != nil || logger.policyStateCount != nil {
+		t.Error("Expected the disabled metrics not to be created")
+	}
+
+	names := logger.MetricNames()
+	for _, name := range []string{"myapp_enforce_total", "myapp_enforce_duration_seconds"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Expected %s in metric names %v", name, names)
+		}
+	}
+	for _, name := range names {
+		if !strings.HasPrefix(name, "myapp_") {
+			t.Errorf("Expected metric %s to have the myapp prefix", name)
+		}
+	}
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "org1", Allowed: true})
+	if count := testutil.CollectAndCount(logger.GetEnforceDuration()); count != 1 {
+		t.Errorf("Expected 1 enforce duration series, got %d", count)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather returned error: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "myapp_enforce_duration_seconds" {
+			if buckets := len(family.GetMetric()[0].GetHistogram().GetBucket()); buckets != 3 {
+				t.Errorf("Expected 3 enforce duration buckets, got %d", buckets)
+			}
+		}
+	}
+}
+
+func TestNewPrometheusLoggerFromConfig_Invalid(t *testing.T) {
+	configs := map[string]LoggerConfig{
+		"summary":          {DurationType: "summary"},
+		"unit":             {DurationUnit: "minutes"},
+		"event type":       {EventTypes: []string{"enforce", "nope"}},
+		"disabled metric":  {DisabledMetrics: []string{"nope"}},
+		"enforce label":    {EnforceLabels: []string{"nope"}},
+		"namespace":        {Namespace: "my-app"},
+		"unsorted buckets": {DurationBuckets: []float64{1, 0.1}},
+	}
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			if _, err := NewPrometheusLoggerFromConfig(registry, cfg); err == nil {
+				t.Error("Expected an error")
+			}
+			if families, _ := registry.Gather(); len(families) != 0 {
+				t.Errorf("Expected no metrics to be registered, got %d families", len(families))
+			}
+		})
+	}
+}
+
+func TestNewPrometheusLoggerFromConfig_RegistrationError(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	first, err := NewPrometheusLoggerFromConfig(registry, LoggerConfig{})
+	if err != nil {
+		t.Fatalf("NewPrometheusLoggerFromConfig returned error: %v", err)
+	}
+	defer first.UnregisterFrom(registry)
+
+	if _, err := NewPrometheusLoggerFromConfig(registry, LoggerConfig{}); err == nil {
+		t.Error("Expected an error registering the same metrics twice")
+	}
+	// The failed registration is rolled back, leaving the first logger's metrics.
+	if !first.UnregisterFrom(registry) {
+		t.Error("Expected the metrics of the first logger to remain registered")
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import "context"
+
+// unknownContextLabelValue is the value of a context label whose context value
+// is missing, not a string, or not one of the allowed values.
+const unknownContextLabelValue = "unknown"
+
+// contextLabel is an enforce label taken from the request context.
+type contextLabel struct {
+	key    any
+	values map[string]bool
+}
+
+// newContextLabels returns the context labels configured by options, by label
+// name.
+func newContextLabels(options *PrometheusLoggerOptions) map[string]contextLabel {
+	if len(options.ContextLabels) == 0 {
+		return nil
+	}
+
+	labels := make(map[string]contextLabel, len(options.ContextLabels))
+	for name, key := range options.ContextLabels {
+		values := make(map[string]bool, len(options.ContextLabelValues[name]))
+		for _, value := range options.ContextLabelValues[name] {
+			values[value] = true
+		}
+		labels[name] = contextLabel{key: key, values: values}
+	}
+	return labels
+}
+
+// value returns the label value carried by ctx.
+func (l contextLabel) value(ctx context.Context) string {
+	if value, ok := ctx.Value(l.key).(string); ok && l.values[value] {
+		return value
+	}
+	return unknownContextLabelValue
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type tenantTierKey struct{}
+
+func TestContextLabels(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		ContextLabels:      map[string]any{"tenant_tier": tenantTierKey{}},
+		ContextLabelValues: map[string][]string{"tenant_tier": {"free", "enterprise"}},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, ctx := range []context.Context{
+		context.WithValue(context.Background(), tenantTierKey{}, "enterprise"),
+		context.WithValue(context.Background(), tenantTierKey{}, "enterprise"),
+		context.WithValue(context.Background(), tenantTierKey{}, "gold"),
+		context.WithValue(context.Background(), tenantTierKey{}, 42),
+		context.Background(),
+	} {
+		logger.OnAfterEventCtx(ctx, &LogEntry{IsActive: true, EventType: EventEnforce, Allowed: true, StartTime: time.Now()})
+	}
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Allowed: true, StartTime: time.Now()})
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",domain="default",tenant_tier="enterprise"} 2
+casbin_enforce_total{allowed="true",domain="default",tenant_tier="unknown"} 4
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestContextLabels_Invalid(t *testing.T) {
+	invalid := []*PrometheusLoggerOptions{
+		{ContextLabels: map[string]any{"tenant_tier": tenantTierKey{}}},
+		{ContextLabels: map[string]any{"tenant-tier": tenantTierKey{}}, ContextLabelValues: map[string][]string{"tenant-tier": {"free"}}},
+		{ContextLabels: map[string]any{"domain": tenantTierKey{}}, ContextLabelValues: map[string][]string{"domain": {"free"}}},
+		{ContextLabels: map[string]any{"tenant_tier": nil}, ContextLabelValues: map[string][]string{"tenant_tier": {"free"}}},
+		{ContextLabelValues: map[string][]string{"tenant_tier": {"free"}}},
+	}
+
+	for _, options := range invalid {
+		if _, err := newPrometheusLogger(options); err == nil {
+			t.Errorf("expected an error for options %+v", options)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// csvHeader lists the columns written by ExportCSV. The order is stable.
+var csvHeader = []string{"metric", "labels", "value"}
+
+// ExportCSV writes the current value of every series owned by the logger to w
+// in CSV format, for offline analysis without access to Prometheus.
+//
+// The first row is the header "metric,labels,value". Each following row holds
+// one series: the metric name, its labels encoded as "name=value" pairs joined
+// by ";" in label name order, and the current value. A "\", ";" or "=" in a
+// label value is escaped with a backslash, so the labels can be split
+// unambiguously. Counters and gauges emit a single row per series; histograms
+// emit one row for "<name>_count" and one for "<name>_sum".
+func (p *PrometheusLogger) ExportCSV(w io.Writer) error {
+	families, err := gatherCollectors(p.collectors()...)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, family := range families {
+		name := family.GetName()
+		for _, metric := range family.GetMetric() {
+			labels := formatCSVLabels(metric.GetLabel())
+			var rows [][]string
+			switch family.GetType() {
+			case dto.MetricType_COUNTER:
+				rows = append(rows, []string{name, labels, formatCSVValue(metric.GetCounter().GetValue())})
+			case dto.MetricType_GAUGE:
+				rows = append(rows, []string{name, labels, formatCSVValue(metric.GetGauge().GetValue())})
+			case dto.MetricType_HISTOGRAM:
+				histogram := metric.GetHistogram()
+				rows = append(rows,
+					[]string{name + "_count", labels, strconv.FormatUint(histogram.GetSampleCount(), 10)},
+					[]string{name + "_sum", labels, formatCSVValue(histogram.GetSampleSum())},
+				)
+			}
+			if err := cw.WriteAll(rows); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvLabelValueEscaper escapes the separators of formatCSVLabels in label
+// values. Label names cannot contain them.
+var csvLabelValueEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, "=", `\=`)
+
+// formatCSVLabels encodes label pairs as "name=value" joined by ";", escaping
+// the separators in the values.
+func formatCSVLabels(pairs []*dto.LabelPair) string {
+	parts := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		parts = append(parts, pair.GetName()+"="+csvLabelValueEscaper.Replace(pair.GetValue()))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ";")
+}
+
+// formatCSVValue formats a sample value without trailing zeros.
+func formatCSVValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestExportCSV(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	for i := 0; i < 3; i++ {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventEnforce,
+			StartTime: time.Now(),
+			Domain:    "domain1",
+			Allowed:   true,
+		})
+	}
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventAddPolicy,
+		StartTime: time.Now(),
+		RuleCount: 4,
+	})
+
+	var buf bytes.Buffer
+	if err := logger.ExportCSV(&buf); err != nil {
+		t.Fatalf("ExportCSV returned error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse exported CSV: %v", err)
+	}
+
+	if len(records) == 0 || records[0][0] != "metric" || records[0][1] != "labels" || records[0][2] != "value" {
+		t.Fatalf("Unexpected header row: %v", records)
+	}
+
+	values := make(map[string]string)
+	for _, record := range records[1:] {
+		values[record[0]+"|"+record[1]] = record[2]
+	}
+
+	expected := map[string]string{
+		"casbin_enforce_total|allowed=true;domain=domain1":                    "3",
+		"casbin_enforce_duration_seconds_count|allowed=true;domain=domain1":   "3",
+		"casbin_policy_operations_total|operation=addPolicy;success=true":     "1",
+		"casbin_policy_rules_count|operation=addPolicy":                       "4",
+		"casbin_policy_operations_duration_seconds_count|operation=addPolicy": "1",
+	}
+	for key, want := range expected {
+		if got, ok := values[key]; !ok {
+			t.Errorf("Expected row %q in CSV output", key)
+		} else if got != want {
+			t.Errorf("Expected value %s for %q, got %s", want, key, got)
+		}
+	}
+}
+
+func TestExportCSV_EscapesLabelValues(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		StartTime: time.Now(),
+		Domain:    `a=b;c\d`,
+		Allowed:   true,
+	})
+
+	var buf bytes.Buffer
+	if err := logger.ExportCSV(&buf); err != nil {
+		t.Fatalf("ExportCSV returned error: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse exported CSV: %v", err)
+	}
+
+	expected := `allowed=true;domain=a\=b\;c\\d`
+	for _, record := range records[1:] {
+		if record[0] == "casbin_enforce_total" {
+			if record[1] != expected {
+				t.Errorf("Expected labels %q, got %q", expected, record[1])
+			}
+			return
+		}
+	}
+	t.Error("Expected a casbin_enforce_total row in CSV output")
+}
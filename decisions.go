@@ -0,0 +1,98 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"errors"
+	"sync"
+)
+
+// decisionLog is a fixed-size ring buffer of the most recent enforce entries.
+type decisionLog struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	// next is the index the next entry is written to, and full reports
+	// whether the buffer has wrapped around.
+	next int
+	full bool
+}
+
+func newDecisionLog(size int) *decisionLog {
+	return &decisionLog{entries: make([]LogEntry, size)}
+}
+
+// add stores a copy of the scalar fields of entry, replacing the oldest entry
+// once the buffer is full. The error is kept as its message only, so that
+// the buffer does not retain the values the error refers to.
+func (d *decisionLog) add(entry *LogEntry) {
+	decision := LogEntry{
+		IsActive:       entry.IsActive,
+		EventType:      entry.EventType,
+		StartTime:      entry.StartTime,
+		EndTime:        entry.EndTime,
+		Duration:       entry.Duration,
+		WaitStart:      entry.WaitStart,
+		EvalStart:      entry.EvalStart,
+		CPUDuration:    entry.CPUDuration,
+		Subject:        entry.Subject,
+		Object:         entry.Object,
+		Action:         entry.Action,
+		Domain:         entry.Domain,
+		Allowed:        entry.Allowed,
+		ExplicitDeny:   entry.ExplicitDeny,
+		DenyCause:      entry.DenyCause,
+		Weight:         entry.Weight,
+		RulesEvaluated: entry.RulesEvaluated,
+		ArgCount:       entry.ArgCount,
+		Retries:        entry.Retries,
+		ErrorCategory:  entry.ErrorCategory,
+	}
+	if entry.CacheHit != nil {
+		cacheHit := *entry.CacheHit
+		decision.CacheHit = &cacheHit
+	}
+	if entry.Error != nil {
+		decision.Error = errors.New(entry.Error.Error())
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries[d.next] = decision
+	d.next++
+	if d.next == len(d.entries) {
+		d.next = 0
+		d.full = true
+	}
+}
+
+// recent returns the stored entries, oldest first.
+func (d *decisionLog) recent() []LogEntry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.full {
+		return append([]LogEntry(nil), d.entries[:d.next]...)
+	}
+	return append(append(make([]LogEntry, 0, len(d.entries)), d.entries[d.next:]...), d.entries[:d.next]...)
+}
+
+// RecentDecisions returns copies of the most recent enforce entries, oldest
+// first, or nil if DecisionLogSize is not set. Only the scalar fields are
+// kept: Rules and Attributes are dropped and Error only keeps its message.
+func (p *PrometheusLogger) RecentDecisions() []LogEntry {
+	if p.decisions == nil {
+		return nil
+	}
+	return p.decisions.recent()
+}
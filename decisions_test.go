@@ -0,0 +1,77 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRecentDecisions(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	const size = 10
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{DecisionLogSize: size})
+	defer logger.UnregisterFrom(registry)
+
+	if decisions := logger.RecentDecisions(); len(decisions) != 0 {
+		t.Fatalf("Expected no decisions, got %d", len(decisions))
+	}
+
+	denyErr := errors.New("matcher failed")
+	for i := 0; i < size+5; i++ {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:   true,
+			EventType:  EventEnforce,
+			Subject:    "user" + strconv.Itoa(i),
+			Allowed:    i%2 == 0,
+			Attributes: map[string]string{"department": "sales"},
+			Error:      denyErr,
+		})
+		// Policy operations are not decisions.
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventAddPolicy})
+	}
+
+	decisions := logger.RecentDecisions()
+	if len(decisions) != size {
+		t.Fatalf("Expected %d decisions, got %d", size, len(decisions))
+	}
+	for i, decision := range decisions {
+		if expected := "user" + strconv.Itoa(i+5); decision.Subject != expected {
+			t.Errorf("Expected decision %d for %s, got %s", i, expected, decision.Subject)
+		}
+		if decision.Allowed != ((i+5)%2 == 0) {
+			t.Errorf("Expected decision %d to be allowed=%v", i, (i+5)%2 == 0)
+		}
+		if decision.Attributes != nil {
+			t.Errorf("Expected decision %d without attributes, got %v", i, decision.Attributes)
+		}
+		if decision.Error == nil || decision.Error == denyErr || decision.Error.Error() != denyErr.Error() {
+			t.Errorf("Expected decision %d to keep only the error message, got %v", i, decision.Error)
+		}
+	}
+
+	// The returned slice is a copy.
+	decisions[0].Subject = "changed"
+	if logger.RecentDecisions()[0].Subject != "user5" {
+		t.Error("Expected RecentDecisions to return a copy")
+	}
+
+	if logger := NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), nil); logger.RecentDecisions() != nil {
+		t.Error("Expected no decision log by default")
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// hllPrecision is the number of hash bits selecting a HyperLogLog register.
+// 2^14 registers give a standard error of about 0.8% in 16 KiB per sketch.
+const hllPrecision = 14
+
+// hllRegisters is the number of registers of a HyperLogLog sketch.
+const hllRegisters = 1 << hllPrecision
+
+// hyperLogLog is a HyperLogLog sketch estimating the number of distinct
+// hashes added to it.
+type hyperLogLog struct {
+	registers [hllRegisters]uint8
+}
+
+// add adds a 64-bit hash to the sketch.
+func (h *hyperLogLog) add(hash uint64) {
+	index := hash >> (64 - hllPrecision)
+	rank := uint8(min(bits.LeadingZeros64(hash<<hllPrecision), 64-hllPrecision) + 1)
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// estimate returns the estimated number of distinct hashes added.
+func (h *hyperLogLog) estimate() float64 {
+	var sum float64
+	zeros := 0
+	for _, register := range h.registers {
+		sum += math.Ldexp(1, -int(register))
+		if register == 0 {
+			zeros++
+		}
+	}
+
+	m := float64(hllRegisters)
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// Linear counting is more accurate for small cardinalities. With 64-bit
+	// hashes no correction for large cardinalities is needed.
+	if estimate <= 2.5*m && zeros > 0 {
+		return m * math.Log(m/float64(zeros))
+	}
+	return estimate
+}
+
+// distinctSubjectsCollector is a collector of
+// casbin_enforce_distinct_subjects, the approximate number of distinct
+// subjects of enforce requests by domain, estimated at scrape time from a
+// HyperLogLog sketch per domain.
+type distinctSubjectsCollector struct {
+	desc *prometheus.Desc
+	seed maphash.Seed
+
+	mu       sync.Mutex
+	sketches map[string]*hyperLogLog
+}
+
+// newDistinctSubjectsCollector creates a distinct subjects collector.
+func newDistinctSubjectsCollector(options *PrometheusLoggerOptions) *distinctSubjectsCollector {
+	return &distinctSubjectsCollector{
+		desc: prometheus.NewDesc(
+			options.metricName("casbin_enforce_distinct_subjects", gaugeMetric),
+			options.metricHelp("casbin_enforce_distinct_subjects", "Approximate number of distinct subjects of enforce requests by domain"),
+			[]string{"domain"}, nil,
+		),
+		seed:     maphash.MakeSeed(),
+		sketches: make(map[string]*hyperLogLog),
+	}
+}
+
+// observe adds subject to the sketch of domain.
+func (c *distinctSubjectsCollector) observe(domain, subject string) {
+	hash := maphash.String(c.seed, subject)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sketch, ok := c.sketches[domain]
+	if !ok {
+		sketch = &hyperLogLog{}
+		c.sketches[domain] = sketch
+	}
+	sketch.add(hash)
+}
+
+// Describe implements prometheus.Collector.
+func (c *distinctSubjectsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *distinctSubjectsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for domain, sketch := range c.sketches {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, math.Round(sketch.estimate()), domain)
+	}
+}
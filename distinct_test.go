@@ -0,0 +1,79 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestEnforceDistinctSubjects(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceDistinctSubjects: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	const distinct = 50000
+	// Repeated subjects do not add to the estimate.
+	for round := 0; round < 2; round++ {
+		for i := 0; i < distinct; i++ {
+			logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", Subject: "user" + strconv.Itoa(i)})
+		}
+	}
+	for i := 0; i < 20; i++ {
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain2", Subject: "user" + strconv.Itoa(i%5)})
+	}
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain3"})
+
+	families, err := gatherCollectors(logger.GetEnforceDistinctSubjects())
+	if err != nil {
+		t.Fatal(err)
+	}
+	estimates := make(map[string]float64)
+	for _, metric := range families[0].GetMetric() {
+		estimates[labelMap(metric.GetLabel())["domain"]] = metric.GetGauge().GetValue()
+	}
+
+	// Four standard errors of 0.8%, as the hash seed is random.
+	if estimate := estimates["domain1"]; math.Abs(estimate-distinct)/distinct > 0.035 {
+		t.Errorf("Expected about %d distinct subjects in domain1, got %v", distinct, estimate)
+	}
+	if estimate := estimates["domain2"]; estimate != 5 {
+		t.Errorf("Expected 5 distinct subjects in domain2, got %v", estimate)
+	}
+	if _, ok := estimates["domain3"]; ok {
+		t.Error("Expected entries without a subject not to be counted")
+	}
+}
+
+func TestHyperLogLog_Empty(t *testing.T) {
+	if estimate := (&hyperLogLog{}).estimate(); estimate != 0 {
+		t.Errorf("Expected an empty sketch to estimate 0, got %v", estimate)
+	}
+}
+
+func TestHyperLogLog_TenThousand(t *testing.T) {
+	collector := newDistinctSubjectsCollector(&PrometheusLoggerOptions{})
+	for i := 0; i < 10000; i++ {
+		collector.observe("domain1", "subject-"+strconv.Itoa(i))
+	}
+	if estimate := collector.sketches["domain1"].estimate(); math.Abs(estimate-10000)/10000 > 0.035 {
+		t.Errorf("Expected an estimate within 3.5%% of 10000, got %v", estimate)
+	}
+}
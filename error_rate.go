@@ -0,0 +1,108 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// errorRateSlots is the number of slots the error rate window is divided into.
+const errorRateSlots = 60
+
+// errorRateSlot counts the enforce requests of one slot of the window.
+type errorRateSlot struct {
+	// index is the number of slot widths since the Unix epoch of the start
+	// of the slot, used to detect slots left over from an earlier window.
+	index  int64
+	total  uint64
+	errors uint64
+}
+
+// errorRateCollector is a collector of casbin_enforce_error_rate, the ratio of
+// enforce requests with an error to all enforce requests within a rolling
+// window, computed at scrape time.
+type errorRateCollector struct {
+	desc  *prometheus.Desc
+	width time.Duration
+	now   func() time.Time
+
+	mu    sync.Mutex
+	slots [errorRateSlots]errorRateSlot
+}
+
+// newErrorRateCollector creates an error rate collector over the
+// EnforceErrorRateWindow of options, reading the time from now.
+func newErrorRateCollector(options *PrometheusLoggerOptions, now func() time.Time) *errorRateCollector {
+	return &errorRateCollector{
+		desc: prometheus.NewDesc(
+			options.metricName("casbin_enforce_error_rate", gaugeMetric),
+			options.metricHelp("casbin_enforce_error_rate", "Ratio of enforce requests with an error within a rolling window"),
+			nil, nil,
+		),
+		width: max(options.EnforceErrorRateWindow/errorRateSlots, 1),
+		now:   now,
+	}
+}
+
+// observe counts an enforce request, which failed if failed is set.
+func (c *errorRateCollector) observe(failed bool) {
+	index := c.now().UnixNano() / int64(c.width)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	slot := &c.slots[index%errorRateSlots]
+	if slot.index != index {
+		*slot = errorRateSlot{index: index}
+	}
+	slot.total++
+	if failed {
+		slot.errors++
+	}
+}
+
+// rate returns the error rate within the window, or 0 if no enforce request
+// was recorded in it.
+func (c *errorRateCollector) rate() float64 {
+	index := c.now().UnixNano() / int64(c.width)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var total, errors uint64
+	for _, slot := range c.slots {
+		if index-slot.index < errorRateSlots {
+			total += slot.total
+			errors += slot.errors
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(errors) / float64(total)
+}
+
+// Describe implements prometheus.Collector.
+func (c *errorRateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+// Collect implements prometheus.Collector.
+func (c *errorRateCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, c.rate())
+}
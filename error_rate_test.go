@@ -0,0 +1,69 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestEnforceErrorRate(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceErrorRateWindow: time.Minute,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time { return now }
+	record := func(err error) {
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: now, Error: err})
+	}
+
+	if value := testutil.ToFloat64(logger.GetEnforceErrorRate()); value != 0 {
+		t.Errorf("Expected an error rate of 0 without requests, got %v", value)
+	}
+
+	record(errors.New("matcher error"))
+	record(nil)
+	now = now.Add(30 * time.Second)
+	record(nil)
+	record(nil)
+
+	expected := `
+# HELP casbin_enforce_error_rate Ratio of enforce requests with an error within a rolling window
+# TYPE casbin_enforce_error_rate gauge
+casbin_enforce_error_rate 0.25
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_error_rate"); err != nil {
+		t.Error(err)
+	}
+
+	// The first two requests leave the window.
+	now = now.Add(45 * time.Second)
+	if value := testutil.ToFloat64(logger.GetEnforceErrorRate()); value != 0 {
+		t.Errorf("Expected an error rate of 0 after the error expired, got %v", value)
+	}
+
+	record(errors.New("matcher error"))
+	if value := testutil.ToFloat64(logger.GetEnforceErrorRate()); value != 1.0/3 {
+		t.Errorf("Expected an error rate of 1/3, got %v", value)
+	}
+}
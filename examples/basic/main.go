@@ -22,7 +22,6 @@ import (
 
 	prometheuslogger "github.com/casbin/casbin-prometheus-logger"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
@@ -59,14 +58,15 @@ func main() {
 	// Simulate some policy operations
 	simulatePolicyEvents(logger)
 
-	// Start HTTP server to expose metrics
-	http.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	// Start HTTP server to expose metrics. Pass a *tls.Config instead of
+	// nil and call ListenAndServeTLS to serve them over TLS.
+	server := prometheuslogger.NewMetricsServer(":8080", registry, nil)
 	
 	fmt.Println("Starting metrics server on :8080")
 	fmt.Println("Visit http://localhost:8080/metrics to see the metrics")
 	
 	go func() {
-		if err := http.ListenAndServe(":8080", nil); err != nil {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Failed to start metrics server: %v", err)
 		}
 	}()
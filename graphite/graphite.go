@@ -0,0 +1,94 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graphite provides a casbin log callback that writes enforce events
+// in the Graphite plaintext protocol, for monitoring systems that ingest
+// Graphite rather than scrape Prometheus.
+package graphite
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	prometheuslogger "github.com/casbin/casbin-prometheus-logger"
+)
+
+// NewGraphiteCallback returns a log callback that writes two Graphite
+// plaintext lines to w for each enforce event:
+//
+//	<prefix>.enforce.<domain>.<allow|deny> 1 <timestamp>
+//	<prefix>.enforce.<domain>.duration_seconds <duration> <timestamp>
+//
+// The timestamp is the entry's EndTime in Unix seconds, and an empty domain is
+// reported as "default". Characters that are not valid in a Graphite path
+// component are replaced by underscores. Other event types are ignored.
+//
+// Writes are serialized, so the callback may be shared by concurrent
+// enforcers.
+func NewGraphiteCallback(w io.Writer, prefix string) func(entry *prometheuslogger.LogEntry) error {
+	prefix = strings.TrimSuffix(prefix, ".")
+	var mu sync.Mutex
+
+	return func(entry *prometheuslogger.LogEntry) error {
+		if entry.EventType != prometheuslogger.EventEnforce && entry.EventType != prometheuslogger.EventEnforceEx {
+			return nil
+		}
+
+		domain := entry.Domain
+		if domain == "" {
+			domain = "default"
+		}
+		path := sanitize(domain)
+		if prefix != "" {
+			path = prefix + ".enforce." + path
+		} else {
+			path = "enforce." + path
+		}
+
+		decision := "deny"
+		if entry.Allowed {
+			decision = "allow"
+		}
+
+		end := entry.EndTime
+		if end.IsZero() {
+			end = time.Now()
+		}
+		timestamp := end.Unix()
+		duration := strconv.FormatFloat(entry.EndTime.Sub(entry.StartTime).Seconds(), 'f', -1, 64)
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, err := fmt.Fprintf(w, "%s.%s 1 %d\n%s.duration_seconds %s %d\n",
+			path, decision, timestamp, path, duration, timestamp)
+		return err
+	}
+}
+
+// sanitize replaces the characters of s that would break a Graphite path
+// component.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
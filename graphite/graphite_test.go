@@ -0,0 +1,62 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphite
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	prometheuslogger "github.com/casbin/casbin-prometheus-logger"
+)
+
+// plaintextLine matches a Graphite plaintext line: "<path> <value> <timestamp>".
+var plaintextLine = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)* -?[0-9]+(\.[0-9]+)? [0-9]+$`)
+
+func TestNewGraphiteCallback(t *testing.T) {
+	var buf bytes.Buffer
+	callback := NewGraphiteCallback(&buf, "casbin")
+
+	start := time.Unix(1700000000, 0)
+	entries := []*prometheuslogger.LogEntry{
+		{EventType: prometheuslogger.EventEnforce, Domain: "tenant.a", Allowed: true, StartTime: start, EndTime: start.Add(250 * time.Millisecond)},
+		{EventType: prometheuslogger.EventEnforce, Allowed: false, StartTime: start, EndTime: start.Add(time.Second)},
+		{EventType: prometheuslogger.EventAddPolicy, StartTime: start, EndTime: start},
+	}
+	for _, entry := range entries {
+		if err := callback(entry); err != nil {
+			t.Fatalf("callback returned %v", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	for _, line := range lines {
+		if !plaintextLine.MatchString(line) {
+			t.Errorf("line %q does not match the Graphite plaintext grammar", line)
+		}
+	}
+
+	expected := []string{
+		"casbin.enforce.tenant_a.allow 1 1700000000",
+		"casbin.enforce.tenant_a.duration_seconds 0.25 1700000000",
+		"casbin.enforce.default.deny 1 1700000001",
+		"casbin.enforce.default.duration_seconds 1 1700000001",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected lines:\n%s\ngot:\n%s", strings.Join(expected, "\n"), buf.String())
+	}
+}
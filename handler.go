@@ -0,0 +1,125 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewMetricsServer returns an http.Server serving the metrics of registry on
+// /metrics at addr, or those of the default Prometheus registry if registry
+// is nil. With a non-nil tlsConfig the server uses it, for example with
+// ClientAuth set to tls.RequireAndVerifyClientCert for mTLS scraping, and
+// must be started with ListenAndServeTLS; its certificate files may be
+// empty if tlsConfig provides the certificates. Otherwise it serves plain
+// HTTP with ListenAndServe. Stop it gracefully with Shutdown.
+func NewMetricsServer(addr string, registry *prometheus.Registry, tlsConfig *tls.Config) *http.Server {
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if registry != nil {
+		gatherer = registry
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{}))
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// AuthMiddleware returns an http.Handler that passes requests to next only if
+// they carry the header "Authorization: Bearer <token>", and responds with
+// 401 Unauthorized otherwise. Wrap the handler of a metrics server with it,
+// e.g. server.Handler = AuthMiddleware(token, server.Handler). An empty
+// token rejects every request.
+func AuthMiddleware(token string, next http.Handler) http.Handler {
+	// Comparing hashes of equal length keeps the comparison from revealing
+	// the length of the token, or how much of it matched.
+	want := sha256.Sum256([]byte(token))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		gotHash := sha256.Sum256([]byte(got))
+		if subtle.ConstantTimeCompare(gotHash[:], want[:]) != 1 || !ok || token == "" {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HandlerWithTimeout returns an http.Handler exposing the registry the logger
+// is registered with. If gathering the metrics takes longer than d, the
+// handler responds with 503 Service Unavailable. A non-positive d disables
+// the timeout.
+func (p *PrometheusLogger) HandlerWithTimeout(d time.Duration) http.Handler {
+	return promhttp.HandlerFor(p.gatherer, promhttp.HandlerOpts{Timeout: d})
+}
+
+// MergedHandler returns an http.Handler exposing the registries of several
+// loggers on one endpoint, for example one logger per plugin. Metric families
+// of the same name are merged into one, so their help text and label names
+// must match. Loggers sharing a registry are gathered once. If the same series
+// is reported by two registries, the duplicate is dropped instead of failing
+// the scrape.
+func MergedHandler(loggers ...*PrometheusLogger) http.Handler {
+	var gatherers prometheus.Gatherers
+	for _, logger := range loggers {
+		if !slices.Contains(gatherers, logger.gatherer) {
+			gatherers = append(gatherers, logger.gatherer)
+		}
+	}
+	return promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// TopLabelValuesHandler returns a debug http.Handler listing the most frequent
+// values of an enforce label as "value count" lines, for example
+// /debug/top?label=subject&n=10. The n parameter defaults to 10.
+func (p *PrometheusLogger) TopLabelValuesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label := r.URL.Query().Get("label")
+		if !slices.Contains(p.enforceLabels, label) {
+			http.Error(w, fmt.Sprintf("unknown enforce label %q", label), http.StatusBadRequest)
+			return
+		}
+
+		n := 10
+		if value := r.URL.Query().Get("n"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid n %q", value), http.StatusBadRequest)
+				return
+			}
+			n = parsed
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, count := range p.TopLabelValues(label, n) {
+			fmt.Fprintf(w, "%s %s\n", count.Value, strconv.FormatFloat(count.Count, 'f', -1, 64))
+		}
+	})
+}
@@ -0,0 +1,226 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// slowCollector is a collector that blocks for a delay before collecting nothing.
+type slowCollector struct {
+	delay time.Duration
+}
+
+func (c slowCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c slowCollector) Collect(ch chan<- prometheus.Metric) {
+	time.Sleep(c.delay)
+}
+
+func TestHandlerWithTimeout(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		StartTime: time.Now(),
+		Allowed:   true,
+	})
+
+	recorder := httptest.NewRecorder()
+	logger.HandlerWithTimeout(time.Second).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", recorder.Code)
+	}
+	if !strings.Contains(recorder.Body.String(), "casbin_enforce_total") {
+		t.Error("Expected casbin_enforce_total in the response")
+	}
+}
+
+func TestNewMetricsServer_TLS(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), Allowed: true})
+
+	// Borrow the test certificate of httptest and its client trusting it.
+	certServer := httptest.NewTLSServer(http.NotFoundHandler())
+	defer certServer.Close()
+	client := certServer.Client()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewMetricsServer(listener.Addr().String(), registry, &tls.Config{Certificates: certServer.TLS.Certificates})
+	served := make(chan error, 1)
+	go func() { served <- server.ServeTLS(listener, "", "") }()
+
+	resp, err := client.Get("https://" + listener.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics over TLS returned error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if !strings.Contains(string(body), "casbin_enforce_total") {
+		t.Error("Expected casbin_enforce_total in the response")
+	}
+
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown returned error: %v", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("Expected ErrServerClosed after Shutdown, got %v", err)
+	}
+}
+
+func TestNewMetricsServer_Plaintext(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), Allowed: true})
+
+	server := NewMetricsServer(":0", registry, nil)
+	if server.TLSConfig != nil {
+		t.Error("Expected no TLS config")
+	}
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), "casbin_enforce_total") {
+		t.Errorf("Expected status 200 with metrics, got %d", recorder.Code)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), Allowed: true})
+
+	server := NewMetricsServer(":0", registry, nil)
+	handler := AuthMiddleware("s3cret", server.Handler)
+
+	testCases := []struct {
+		name          string
+		authorization string
+		expected      int
+	}{
+		{"missing header", "", http.StatusUnauthorized},
+		{"wrong token", "Bearer wrong!", http.StatusUnauthorized},
+		{"shorter token", "Bearer s3cre", http.StatusUnauthorized},
+		{"longer token", "Bearer s3cret-and-more", http.StatusUnauthorized},
+		{"wrong scheme", "Basic s3cret", http.StatusUnauthorized},
+		{"bare token", "s3cret", http.StatusUnauthorized},
+		{"correct token", "Bearer s3cret", http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.authorization != "" {
+				request.Header.Set("Authorization", tc.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			if recorder.Code != tc.expected {
+				t.Errorf("Expected status %d, got %d", tc.expected, recorder.Code)
+			}
+			if tc.expected == http.StatusOK && !strings.Contains(recorder.Body.String(), "casbin_enforce_total") {
+				t.Error("Expected casbin_enforce_total in the response")
+			}
+			if tc.expected == http.StatusUnauthorized && recorder.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Error("Expected a WWW-Authenticate: Bearer header")
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware_EmptyToken(t *testing.T) {
+	handler := AuthMiddleware("", http.NotFoundHandler())
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	request.Header.Set("Authorization", "Bearer ")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an empty token to reject every request, got status %d", recorder.Code)
+	}
+}
+
+func TestHandlerWithTimeout_SlowGatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	registry.MustRegister(slowCollector{delay: 500 * time.Millisecond})
+
+	start := time.Now()
+	recorder := httptest.NewRecorder()
+	logger.HandlerWithTimeout(50*time.Millisecond).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	elapsed := time.Since(start)
+
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503, got %d", recorder.Code)
+	}
+	if elapsed >= 500*time.Millisecond {
+		t.Errorf("Expected the handler to return within the timeout, took %v", elapsed)
+	}
+}
+
+func TestMergedHandler(t *testing.T) {
+	registry1 := prometheus.NewRegistry()
+	logger1 := NewPrometheusLoggerWithRegistry(registry1)
+	defer logger1.UnregisterFrom(registry1)
+
+	registry2 := prometheus.NewRegistry()
+	logger2 := NewPrometheusLoggerWithRegistry(registry2)
+	defer logger2.UnregisterFrom(registry2)
+
+	logger1.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "plugin1", Allowed: true, StartTime: time.Now()})
+	logger2.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "plugin2", StartTime: time.Now()})
+
+	recorder := httptest.NewRecorder()
+	MergedHandler(logger1, logger2, logger1).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", recorder.Code)
+	}
+
+	body := recorder.Body.String()
+	for _, series := range []string{
+		`casbin_enforce_total{allowed="true",domain="plugin1"} 1`,
+		`casbin_enforce_total{allowed="false",domain="plugin2"} 1`,
+	} {
+		if !strings.Contains(body, series) {
+			t.Errorf("Expected %s in the merged output", series)
+		}
+	}
+	if count := strings.Count(body, "# HELP casbin_enforce_total "); count != 1 {
+		t.Errorf("Expected one HELP line for casbin_enforce_total, got %d", count)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Merge adds the current metric values of other into p, for example to
+// combine per-worker loggers into one that is scraped.
+//
+// Counters are added, so the merged counter is the sum of both. Gauges take
+// the larger of both values, because they describe shared state such as the
+// policy size rather than per-worker amounts. Histograms cannot be merged from
+// their exported buckets and are left unchanged. Series whose labels do not
+// match the metric of p, because the loggers were configured differently, are
+// skipped.
+//
+// Merge does not reset other, so merging the same logger twice adds its
+// counters twice. Merge is safe to call while p is reconfigured, but other
+// must not be reconfigured concurrently.
+func (p *PrometheusLogger) Merge(other *PrometheusLogger) {
+	if other == p {
+		return
+	}
+
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	fields, otherFields := p.metricFields(), other.metricFields()
+	for i, field := range fields {
+		source := loadCollector(otherFields[i])
+		if loadCollector(field) == nil || source == nil {
+			continue
+		}
+		families, err := gatherCollectors(source)
+		if err != nil {
+			continue
+		}
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				mergeMetric(field, labelMap(metric.GetLabel()), metric)
+			}
+		}
+	}
+}
+
+// mergeMetric merges one series of another logger into the metric field.
+func mergeMetric(field any, labels prometheus.Labels, metric *dto.Metric) {
+	switch f := field.(type) {
+	case **prometheus.CounterVec:
+		if counter, err := (*f).GetMetricWith(labels); err == nil {
+			counter.Add(metric.GetCounter().GetValue())
+		}
+	case *prometheus.Counter:
+		(*f).Add(metric.GetCounter().GetValue())
+	case **prometheus.GaugeVec:
+		if gauge, err := (*f).GetMetricWith(labels); err == nil {
+			mergeGauge(gauge, metric.GetGauge().GetValue())
+		}
+	case *prometheus.Gauge:
+		mergeGauge(*f, metric.GetGauge().GetValue())
+	}
+}
+
+// mergeGauge raises gauge to value if it is larger.
+func mergeGauge(gauge prometheus.Gauge, value float64) {
+	var current dto.Metric
+	if err := gauge.Write(&current); err == nil && current.GetGauge().GetValue() >= value {
+		return
+	}
+	gauge.Set(value)
+}
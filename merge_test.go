@@ -0,0 +1,74 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMerge(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	combined := NewPrometheusLoggerWithRegistry(registry)
+	defer combined.UnregisterFrom(registry)
+
+	worker1 := NewPrometheusLoggerWithRegistry(prometheus.NewRegistry())
+	worker2 := NewPrometheusLoggerWithRegistry(prometheus.NewRegistry())
+
+	enforce := func(logger *PrometheusLogger, domain string, allowed bool) {
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: domain, Allowed: allowed, StartTime: time.Now()})
+	}
+	enforce(worker1, "domain1", true)
+	enforce(worker1, "domain1", true)
+	enforce(worker1, "domain2", false)
+	enforce(worker2, "domain1", true)
+	worker1.UpdatePolicyState("p", 10)
+	worker2.UpdatePolicyState("p", 12)
+
+	combined.Merge(worker1)
+	combined.Merge(worker2)
+	combined.Merge(combined)
+
+	if value := testutil.ToFloat64(combined.GetEnforceTotal().WithLabelValues("true", "domain1")); value != 3 {
+		t.Errorf("Expected 3 merged allowed enforces for domain1, got %v", value)
+	}
+	if value := testutil.ToFloat64(combined.GetEnforceTotal().WithLabelValues("false", "domain2")); value != 1 {
+		t.Errorf("Expected 1 merged denied enforce for domain2, got %v", value)
+	}
+	if value := testutil.ToFloat64(combined.GetPolicyStateCount().WithLabelValues("p")); value != 12 {
+		t.Errorf("Expected the larger policy state 12, got %v", value)
+	}
+}
+
+func TestMerge_MismatchedLabels(t *testing.T) {
+	combined := NewPrometheusLoggerWithRegistry(prometheus.NewRegistry())
+	worker := NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelSubject},
+	})
+
+	worker.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Subject: "alice", StartTime: time.Now()})
+	worker.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventAddPolicy, StartTime: time.Now()})
+	combined.Merge(worker)
+
+	if count := testutil.CollectAndCount(combined.GetEnforceTotal()); count != 0 {
+		t.Errorf("Expected mismatched enforce series to be skipped, got %d", count)
+	}
+	if value := testutil.ToFloat64(combined.GetPolicyOpsTotal().WithLabelValues("addPolicy", "true")); value != 1 {
+		t.Errorf("Expected matching policy series to be merged, got %v", value)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// observer records a custom measurement of each event in a histogram.
+type observer struct {
+	histogram prometheus.Histogram
+	measure   func(entry *LogEntry) (float64, bool)
+}
+
+// AddObserver registers a histogram named name with buckets, or
+// prometheus.DefBuckets if buckets is nil, and observes the value f returns
+// for each active event whose bool result is true, for example a business
+// measurement derived from the decision. The name is used as given. The
+// histogram is registered with the registry of the logger, and is
+// unregistered, mirrored and kept across Reconfigure along with the other
+// metrics of the logger. A logger created by NewPrometheusLoggerWithFactory
+// does not know its registry, so AddObserver returns ErrFactoryRegistry. f is
+// called concurrently and must be fast.
+func (p *PrometheusLogger) AddObserver(name string, buckets []float64, f func(entry *LogEntry) (float64, bool)) error {
+	if !metricNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+		return fmt.Errorf("invalid observer name %q", name)
+	}
+	if f == nil {
+		return errors.New("observer function must not be nil")
+	}
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	if len(buckets) == 0 {
+		return errors.New("observer buckets must not be empty")
+	}
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("observer buckets must be strictly increasing, got %v", buckets)
+		}
+	}
+
+	p.reconfigureMu.Lock()
+	defer p.reconfigureMu.Unlock()
+
+	registerer, err := p.registerer()
+	if err != nil {
+		return err
+	}
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    name,
+		Help:    fmt.Sprintf("Values observed by the %s observer", name),
+		Buckets: buckets,
+	})
+	if err := registerer.Register(histogram); err != nil {
+		return fmt.Errorf("registering observer %s: %w", name, err)
+	}
+	p.observers = append(p.observers, observer{histogram: histogram, measure: f})
+	return nil
+}
+
+// observe records entry in the histogram of each observer whose function
+// returns a value for it.
+func (p *PrometheusLogger) observe(entry *LogEntry) {
+	for _, o := range p.observers {
+		if value, ok := o.measure(entry); ok {
+			o.histogram.Observe(value)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// ruleCountObserver measures the rule count of policy operations.
+func ruleCountObserver(entry *LogEntry) (float64, bool) {
+	if entry.EventType == EventEnforce || entry.EventType == EventEnforceEx {
+		return 0, false
+	}
+	return float64(entry.RuleCount), true
+}
+
+func TestAddObserver(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	if err := logger.AddObserver("app_policy_rule_count", []float64{1, 10, 100}, ruleCountObserver); err != nil {
+		t.Fatalf("AddObserver failed: %v", err)
+	}
+
+	for _, entry := range []*LogEntry{
+		{EventType: EventAddPolicies, RuleCount: 5},
+		{EventType: EventLoadPolicy, RuleCount: 50},
+		{EventType: EventRemovePolicy, RuleCount: 1},
+		{EventType: EventEnforce},
+		{EventType: EventAddPolicies, RuleCount: 500},
+	} {
+		entry.IsActive = true
+		logger.OnAfterEvent(entry)
+	}
+	// Inactive events are not observed.
+	logger.OnAfterEvent(&LogEntry{EventType: EventAddPolicies, RuleCount: 5})
+
+	expected := `
+# HELP app_policy_rule_count Values observed by the app_policy_rule_count observer
+# TYPE app_policy_rule_count histogram
+app_policy_rule_count_bucket{le="1"} 1
+app_policy_rule_count_bucket{le="10"} 2
+app_policy_rule_count_bucket{le="100"} 3
+app_policy_rule_count_bucket{le="+Inf"} 4
+app_policy_rule_count_sum 556
+app_policy_rule_count_count 4
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "app_policy_rule_count"); err != nil {
+		t.Error(err)
+	}
+
+	// The observer survives Reconfigure and is unregistered with the logger.
+	if err := logger.Reconfigure(&PrometheusLoggerOptions{NormalizeLabelValues: true}); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventAddPolicy, RuleCount: 1})
+	if count, err := testutil.GatherAndCount(registry, "app_policy_rule_count"); err != nil || count != 1 {
+		t.Errorf("Expected the observer to stay registered after Reconfigure, got %d series, error %v", count, err)
+	}
+	if !logger.UnregisterFrom(registry) {
+		t.Error("Expected every metric, including the observer, to be unregistered")
+	}
+	if count, _ := testutil.GatherAndCount(registry, "app_policy_rule_count"); count != 0 {
+		t.Errorf("Expected the observer to be unregistered, got %d series", count)
+	}
+}
+
+func TestAddObserver_Invalid(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	for _, tc := range []struct {
+		name    string
+		buckets []float64
+		f       func(*LogEntry) (float64, bool)
+	}{
+		{"app-rule-count", nil, ruleCountObserver},
+		{"__app_rule_count", nil, ruleCountObserver},
+		{"app_rule_count", nil, nil},
+		{"app_rule_count", []float64{}, ruleCountObserver},
+		{"app_rule_count", []float64{10, 1}, ruleCountObserver},
+		{"casbin_enforce_total", nil, ruleCountObserver},
+	} {
+		if err := logger.AddObserver(tc.name, tc.buckets, tc.f); err == nil {
+			t.Errorf("Expected an error for observer %q with buckets %v", tc.name, tc.buckets)
+		}
+	}
+	if len(logger.observers) != 0 {
+		t.Errorf("Expected no observers to be added, got %d", len(logger.observers))
+	}
+}
+
+func TestAddObserver_FactoryLogger(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithFactory(promauto.With(registry))
+	defer logger.UnregisterFrom(registry)
+
+	if err := logger.AddObserver("my_obs", nil, ruleCountObserver); !errors.Is(err, ErrFactoryRegistry) {
+		t.Fatalf("Expected ErrFactoryRegistry, got %v", err)
+	}
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventAddPolicy, RuleCount: 3})
+	if count, err := testutil.GatherAndCount(registry, "my_obs"); err != nil || count != 0 {
+		t.Errorf("Expected no observer histogram in the registry, got %d (%v)", count, err)
+	}
+	if logger.observers != nil {
+		t.Error("Expected the observer not to be added")
+	}
+}
@@ -0,0 +1,644 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// metricKind is the type of a metric, which determines its naming conventions.
+type metricKind int
+
+const (
+	counterMetric metricKind = iota
+	gaugeMetric
+	histogramMetric
+)
+
+// DurationUnit is the unit duration metrics are recorded in.
+type DurationUnit string
+
+// Duration units.
+const (
+	Seconds      DurationUnit = "seconds"
+	Milliseconds DurationUnit = "milliseconds"
+)
+
+// PrometheusLoggerOptions configures a PrometheusLogger created with
+// NewPrometheusLoggerWithOptions. The zero value matches NewPrometheusLogger.
+type PrometheusLoggerOptions struct {
+	// EnforceLabels are the labels of the enforce metrics, chosen from
+	// LabelAllowed, LabelDomain, LabelSubject, LabelObject, LabelAction,
+	// LabelOutcome, LabelDenyType and LabelEventType. Defaults to
+	// DefaultEnforceLabels.
+	//
+	// The labels are always used in the order allowed, outcome, deny_type,
+	// event_type, domain, subject, object, action, whatever order they are
+	// given in.
+	EnforceLabels []string
+
+	// Namespace replaces the "casbin" prefix of the metric names, e.g.
+	// "myapp" registers myapp_enforce_total. Defaults to "casbin".
+	Namespace string
+
+	// MaxSeries caps the number of distinct enforce label combinations.
+	// Once the cap is reached, new combinations are recorded with every
+	// label except "allowed", "outcome", "deny_type" and "event_type" set to
+	// "__overflow__" and counted in casbin_enforce_series_overflow_total.
+	// Zero means unlimited.
+	MaxSeries int
+
+	// IdleSeriesTTL starts a background sweeper that deletes enforce series
+	// not recorded within the TTL, so stale label combinations stop being
+	// exported. The sweep runs once per TTL until Close is called or
+	// BaseContext is cancelled. Zero disables the sweeper.
+	IdleSeriesTTL time.Duration
+
+	// BaseContext is the context the background goroutines of the logger,
+	// such as the idle series sweeper, derive theirs from, so cancelling it
+	// stops them all, like Close does. Nil uses context.Background.
+	BaseContext context.Context
+
+	// EnforceByHour enables casbin_enforce_by_hour_total, counting enforce
+	// requests by the hour of day (0-23) they completed in and by "allowed".
+	EnforceByHour bool
+	// EnforceByHourLocation is the time zone used to derive the hour.
+	// Defaults to UTC.
+	EnforceByHourLocation *time.Location
+
+	// NormalizeLabelValues trims surrounding whitespace from and lowercases
+	// the domain, subject, object and action label values, so that values
+	// such as "Org1 " and "org1" are recorded in the same series.
+	NormalizeLabelValues bool
+
+	// SanitizeUTF8 replaces invalid UTF-8 sequences in the domain, subject,
+	// object, action, attribute and context label values of enforce
+	// requests with the Unicode replacement character, so that a malformed
+	// value from a caller cannot break consumers of the metrics.
+	SanitizeUTF8 bool
+
+	// ActionClassifier maps the action of enforce requests to the value of
+	// the action label, such as "read" for "GET" and "HEAD", keeping its
+	// cardinality low. It receives the action before NormalizeLabelValues
+	// is applied, and its result is recorded as is. Nil records the action
+	// unchanged.
+	ActionClassifier func(action string) string
+
+	// ObjectGrouper maps the object of enforce requests to the value of the
+	// object label, such as the route template "/users/{id}" for the path
+	// "/users/42", keeping its cardinality bounded. PathTemplateGrouper
+	// does this for URL paths. It receives the object before
+	// NormalizeLabelValues is applied, and its result is recorded as is. Nil
+	// records the object unchanged.
+	ObjectGrouper func(object string) string
+
+	// EnforceWeighted enables casbin_enforce_weighted_total, which adds the
+	// Weight of each enforce request by domain.
+	EnforceWeighted bool
+
+	// StrictNaming adjusts metric names to the Prometheus naming conventions
+	// checked by promlint: counters end in "_total", and gauges and
+	// histograms do not end in "_total", "_count", "_sum" or "_bucket". For
+	// example casbin_policy_rules_count becomes casbin_policy_rules. Use
+	// MetricNames to list the resulting names.
+	StrictNaming bool
+
+	// SLOThresholds maps an enforce action to its latency objective. When
+	// set, casbin_enforce_slo_violations_total counts the enforce requests
+	// whose duration exceeded the threshold of their action. Actions
+	// without a threshold are not checked.
+	SLOThresholds map[string]time.Duration
+
+	// EnforceLatencyEWMAAlpha enables casbin_enforce_latency_ewma_seconds, a
+	// gauge by domain of the exponentially weighted moving average of the
+	// enforce duration, for status pages that cannot query Prometheus. Each
+	// request moves the average by alpha times its difference to the
+	// request's duration, so it must be in (0, 1]. Zero disables the gauge.
+	EnforceLatencyEWMAAlpha float64
+
+	// RecoverCallbackPanics recovers panics raised by the log callback, so a
+	// faulty callback cannot crash the goroutine running Enforce. The panic
+	// is returned from OnAfterEvent as an error wrapping ErrCallbackPanic
+	// and counted in casbin_callback_panics_total. Metrics are recorded
+	// before the callback runs either way.
+	RecoverCallbackPanics bool
+
+	// PruneOnDisable deletes the series of event types that SetEventTypes
+	// disables, so they stop being exported: the policy operation series of
+	// a disabled policy event type, and every enforce series if enforce
+	// events are disabled.
+	PruneOnDisable bool
+
+	// CallbackTimeout runs the log callback in a goroutine and stops waiting
+	// for it after the timeout, so a callback blocked on a slow sink cannot
+	// stall Enforce. OnAfterEvent then returns an error wrapping
+	// ErrCallbackTimeout and counts it in casbin_callback_timeouts_total.
+	// The callback keeps running in its goroutine until it returns, so a
+	// callback that never returns leaks one goroutine per event. The
+	// callback receives a copy of the entry. Zero runs the callback inline.
+	CallbackTimeout time.Duration
+
+	// CallbackDuration enables casbin_callback_duration_seconds, a histogram
+	// of how long the log callback takes, to separate the logging overhead,
+	// such as audit writes, from the decision latency. With CallbackTimeout
+	// the callbacks that time out are observed once they return.
+	CallbackDuration bool
+
+	// EnforceRulesEvaluated enables casbin_enforce_rules_evaluated, a
+	// histogram by domain of the RulesEvaluated of enforce requests that set it.
+	EnforceRulesEvaluated bool
+
+	// CountOnlyDuration records casbin_enforce_duration_seconds with only the
+	// +Inf bucket, keeping _count and _sum but not the bucket series. This
+	// reduces the series per label combination from 13 to 3, at the cost of
+	// quantiles such as histogram_quantile no longer being available.
+	CountOnlyDuration bool
+
+	// EnforceDurationBuckets are the buckets of
+	// casbin_enforce_duration_seconds in seconds, which are scaled to the
+	// DurationUnit. They must be strictly increasing and cannot be combined
+	// with CountOnlyDuration. Defaults to prometheus.DefBuckets.
+	EnforceDurationBuckets []float64
+
+	// EnforceDurationTotal enables casbin_enforce_duration_seconds_total, a
+	// counter of the total enforce duration with the enforce labels, so the
+	// average latency rate(duration)/rate(casbin_enforce_total) can be
+	// computed without the histogram.
+	EnforceDurationTotal bool
+
+	// DurationUnit is the unit of the duration metrics, which is also the
+	// suffix of their names, e.g. casbin_enforce_duration_milliseconds with
+	// Milliseconds. Histogram buckets are scaled to match. Defaults to
+	// Seconds, the Prometheus convention.
+	DurationUnit DurationUnit
+
+	// EnforceWaitEval enables casbin_enforce_wait_duration_seconds and
+	// casbin_enforce_eval_duration_seconds, histograms by domain of the time
+	// enforce requests spent queued before EvalStart and evaluating after it.
+	// Entries without EvalStart are not recorded in them.
+	EnforceWaitEval bool
+
+	// EnforceCPU enables casbin_enforce_cpu_seconds, a histogram by domain of
+	// the CPUDuration of enforce requests, to tell CPU-bound matchers from
+	// contention. Entries without CPUDuration are not recorded in it.
+	EnforceCPU bool
+
+	// EnforceRetries enables casbin_enforce_retries_total, the sum by domain
+	// of the Retries of enforce requests, and casbin_enforce_max_retries,
+	// the largest Retries observed.
+	EnforceRetries bool
+
+	// EnforceConsecutiveDenies enables casbin_enforce_consecutive_denies, a
+	// gauge by domain of the current streak of denied enforce requests,
+	// reset to 0 by an allowed one. Spikes can indicate credential stuffing.
+	EnforceConsecutiveDenies bool
+
+	// EnforceCache enables casbin_enforce_cache_hits_total and
+	// casbin_enforce_cache_misses_total, counting by domain the enforce
+	// requests whose CacheHit is set, for enforcers with a decision cache.
+	EnforceCache bool
+
+	// EnforceDenyCause enables casbin_enforce_denies_total, counting denied
+	// enforce requests by domain and LogEntry.DenyCause, for example to
+	// separate subjects without any role from subjects denied by a rule.
+	EnforceDenyCause bool
+
+	// ExpectedArgCount enables casbin_enforce_arg_mismatch_total, counting
+	// enforce requests whose ArgCount is set and differs from it, which
+	// indicates a caller passing the wrong number of request fields to the
+	// enforcer. Zero disables the check.
+	ExpectedArgCount int
+	// OnArgMismatch is called with each enforce entry counted in
+	// casbin_enforce_arg_mismatch_total, for example to log the caller.
+	OnArgMismatch func(entry *LogEntry)
+
+	// EnforceDomainConcurrency enables casbin_enforce_domain_concurrency, a
+	// gauge by domain of the enforce requests between OnBeforeEvent and
+	// OnAfterEvent, and casbin_enforce_domain_concurrency_max, its highest
+	// value, to observe how close tenants get to a concurrency cap. The
+	// domain must be set on the entry before OnBeforeEvent.
+	EnforceDomainConcurrency bool
+
+	// EnforceDistinctSubjects enables casbin_enforce_distinct_subjects, a
+	// gauge by domain of the approximate number of distinct subjects of
+	// enforce requests, estimated with a HyperLogLog sketch per domain
+	// without a subject label. The estimate has a standard error of about
+	// 0.8% and each domain takes 16 KiB.
+	EnforceDistinctSubjects bool
+
+	// EnforceAllowRatio enables casbin_enforce_allow_ratio, a gauge by
+	// domain of the ratio of allowed enforce requests to all enforce
+	// requests since the logger was created, computed at scrape time for
+	// dashboards that cannot divide counters.
+	EnforceAllowRatio bool
+
+	// DecisionLogSize keeps copies of the last DecisionLogSize enforce
+	// entries in memory, returned by RecentDecisions, for example to look
+	// up recent decisions during an incident. Zero disables the log.
+	DecisionLogSize int
+
+	// EnforceErrorRateWindow enables casbin_enforce_error_rate, a gauge of
+	// the ratio of enforce requests with an error to all enforce requests
+	// within the window, so it can be alerted on without rate(). The window
+	// is tracked in 60 slots, so requests expire in steps of a sixtieth of
+	// it. Zero disables the gauge.
+	EnforceErrorRateWindow time.Duration
+
+	// PolicyReloadInterval enables casbin_policy_reload_interval_seconds, a
+	// histogram of the time between consecutive policy load events, to
+	// detect reload storms. The first load records nothing.
+	PolicyReloadInterval bool
+
+	// PolicyOperationErrors enables casbin_policy_operation_errors_total,
+	// counting failed policy operations by operation and "error_category",
+	// taken from the ErrorCategory of the entry, or "unknown" if it is
+	// empty. The categories are those callers choose to set, so they should
+	// come from a small fixed set.
+	PolicyOperationErrors bool
+
+	// PolicyPtypeLabel adds a "ptype" label to casbin_policy_operations_total,
+	// taken from the Ptype of policy operation entries.
+	PolicyPtypeLabel bool
+
+	// PolicyAdapterLabel adds an "adapter" label to
+	// casbin_policy_operations_total and
+	// casbin_policy_operations_duration_seconds, taken from the Adapter of
+	// policy operation entries, since load and save latency depend on it.
+	PolicyAdapterLabel bool
+
+	// PolicySources adds a "source" label to casbin_policy_operations_total
+	// and casbin_policy_operations_duration_seconds, taken from the Source
+	// of policy operation entries, to attribute reloads to the file,
+	// database or watcher that triggered them. Sources not listed are
+	// recorded as "other" and an empty Source as "unknown", bounding the
+	// cardinality. Nil disables the label.
+	PolicySources []string
+
+	// StrictEntries validates each entry with LogEntry.Validate in
+	// OnAfterEvent. Invalid entries are not recorded or passed to the
+	// callback, are counted in casbin_invalid_entries_total, and the
+	// validation error is returned.
+	StrictEntries bool
+
+	// StrictMode checks enforce entries for an Allowed result despite a
+	// non-nil Error. Such entries are counted in
+	// casbin_entry_inconsistencies_total and recorded as not allowed, with
+	// the outcome label "error".
+	StrictMode bool
+
+	// PolicySuccessLabelName renames the "success" label of
+	// casbin_policy_operations_total, e.g. to "status".
+	PolicySuccessLabelName string
+	// PolicySuccessValue and PolicyFailureValue replace the "true" and
+	// "false" values of the policy success label, e.g. with "ok" and "error".
+	PolicySuccessValue string
+	PolicyFailureValue string
+
+	// MetricSink receives the enforce counts and durations and the policy
+	// operation counts and durations instead of casbin_enforce_total,
+	// casbin_enforce_duration_seconds, casbin_enforce_duration_seconds_total,
+	// casbin_policy_operations_total and
+	// casbin_policy_operations_duration_seconds, for example to record them
+	// in another metrics system. Forward to PrometheusSink to record them in
+	// both. Nil records them in Prometheus.
+	MetricSink MetricSink
+
+	// EnabledMetrics restricts the metrics that are created and registered
+	// to the listed ones, named by their default name without the "casbin_"
+	// prefix, such as "enforce_total" or "policy_operations_total". Recording
+	// into a metric that is not listed does nothing. Optional metrics must
+	// also be enabled by their own option. Nil enables every metric.
+	EnabledMetrics []string
+
+	// DisablePolicyStateCount skips creating and registering
+	// casbin_policy_state_count, for callers that never set the policy state.
+	// UpdatePolicyState and UpdatePolicyStateBulk then do nothing and
+	// GetPolicyStateCount returns nil. It is equivalent to leaving
+	// "policy_state_count" out of EnabledMetrics.
+	DisablePolicyStateCount bool
+
+	// MetricNames renames metrics to fully custom names, keyed by their
+	// default name without the "casbin_" prefix like EnabledMetrics, e.g.
+	// "enforce_total" to "authz_decisions_total". The custom names are used
+	// as given, without Namespace, DurationUnit or StrictNaming applied.
+	// Metrics without an entry keep their default name.
+	MetricNames map[string]string
+
+	// MetricHelp overrides the help text of metrics, keyed by their default
+	// name without the "casbin_" prefix like EnabledMetrics, e.g.
+	// "enforce_total". Metrics without an entry keep their default help
+	// text.
+	MetricHelp map[string]string
+
+	// AttributeLabels adds enforce labels taken from LogEntry.Attributes,
+	// named after the attribute keys. They follow the EnforceLabels, sorted
+	// by name. A missing or empty attribute is recorded as "none".
+	AttributeLabels []string
+
+	// ContextLabels adds enforce labels whose values are taken from the
+	// context passed to OnAfterEventCtx, mapping each label name to its
+	// context key. They follow the EnforceLabels, sorted by name.
+	ContextLabels map[string]any
+	// ContextLabelValues lists the allowed values of each context label,
+	// which is required to bound the cardinality. A context value that is
+	// missing, not a string, or not allowed is recorded as "unknown".
+	ContextLabelValues map[string][]string
+}
+
+// metricNameRegexp matches valid Prometheus metric names.
+var metricNameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// labelNameRegexp matches valid Prometheus label names.
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// policySuccessLabel returns the name, success value and failure value of
+// the policy success label.
+func (o *PrometheusLoggerOptions) policySuccessLabel() (name, success, failure string) {
+	name, success, failure = "success", "true", "false"
+	if o.PolicySuccessLabelName != "" {
+		name = o.PolicySuccessLabelName
+	}
+	if o.PolicySuccessValue != "" {
+		success = o.PolicySuccessValue
+	}
+	if o.PolicyFailureValue != "" {
+		failure = o.PolicyFailureValue
+	}
+	return name, success, failure
+}
+
+// validate checks the options for unsupported values.
+func (o *PrometheusLoggerOptions) validate() error {
+	if o.EnforceLabels != nil {
+		if err := validateEnforceLabels(o.EnforceLabels); err != nil {
+			return err
+		}
+	}
+	if o.Namespace != "" && (!labelNameRegexp.MatchString(o.Namespace) || strings.HasPrefix(o.Namespace, "__")) {
+		return fmt.Errorf("invalid namespace %q", o.Namespace)
+	}
+	if o.MaxSeries < 0 {
+		return fmt.Errorf("max series must not be negative, got %d", o.MaxSeries)
+	}
+	for action, threshold := range o.SLOThresholds {
+		if threshold <= 0 {
+			return fmt.Errorf("SLO threshold for action %q must be positive, got %v", action, threshold)
+		}
+	}
+	if o.EnforceLatencyEWMAAlpha < 0 || o.EnforceLatencyEWMAAlpha > 1 {
+		return fmt.Errorf("enforce latency EWMA alpha must be in (0, 1], got %v", o.EnforceLatencyEWMAAlpha)
+	}
+	if o.DecisionLogSize < 0 {
+		return fmt.Errorf("decision log size must not be negative, got %d", o.DecisionLogSize)
+	}
+	if o.ExpectedArgCount < 0 {
+		return fmt.Errorf("expected arg count must not be negative, got %d", o.ExpectedArgCount)
+	}
+	if o.EnforceErrorRateWindow < 0 {
+		return fmt.Errorf("enforce error rate window must not be negative, got %v", o.EnforceErrorRateWindow)
+	}
+	if o.CallbackTimeout < 0 {
+		return fmt.Errorf("callback timeout must not be negative, got %v", o.CallbackTimeout)
+	}
+	if o.IdleSeriesTTL < 0 {
+		return fmt.Errorf("idle series TTL must not be negative, got %v", o.IdleSeriesTTL)
+	}
+
+	if o.EnforceDurationBuckets != nil {
+		if o.CountOnlyDuration {
+			return errors.New("enforce duration buckets cannot be combined with count-only duration")
+		}
+		if len(o.EnforceDurationBuckets) == 0 {
+			return errors.New("enforce duration buckets must not be empty")
+		}
+		for i := 1; i < len(o.EnforceDurationBuckets); i++ {
+			if o.EnforceDurationBuckets[i] <= o.EnforceDurationBuckets[i-1] {
+				return fmt.Errorf("enforce duration buckets must be strictly increasing, got %v", o.EnforceDurationBuckets)
+			}
+		}
+	}
+	if o.DurationUnit != "" && o.DurationUnit != Seconds && o.DurationUnit != Milliseconds {
+		return fmt.Errorf("unsupported duration unit %q", o.DurationUnit)
+	}
+
+	if err := o.validateMetricNames(); err != nil {
+		return err
+	}
+	if err := o.validateAttributeLabels(); err != nil {
+		return err
+	}
+	if err := o.validateContextLabels(); err != nil {
+		return err
+	}
+
+	if slices.Contains(o.PolicySources, "") {
+		return errors.New("policy sources must not be empty")
+	}
+
+	name, success, failure := o.policySuccessLabel()
+	if !labelNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+		return fmt.Errorf("invalid policy success label name %q", name)
+	}
+	if name == "operation" || name == "ptype" || name == "adapter" || name == "source" {
+		return fmt.Errorf("policy success label name %q is already used", name)
+	}
+	if success == failure {
+		return fmt.Errorf("policy success and failure values must differ, both are %q", success)
+	}
+	return nil
+}
+
+// enforceLabelOrder is the canonical order of the enforce labels.
+var enforceLabelOrder = []string{
+	LabelAllowed,
+	LabelOutcome,
+	LabelDenyType,
+	LabelEventType,
+	LabelDomain,
+	LabelSubject,
+	LabelObject,
+	LabelAction,
+}
+
+// validateEnforceLabels checks that labels is a non-empty set of supported enforce labels.
+func validateEnforceLabels(labels []string) error {
+	if len(labels) == 0 {
+		return errors.New("enforce labels must not be empty")
+	}
+
+	seen := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		if !slices.Contains(enforceLabelOrder, label) {
+			return fmt.Errorf("unsupported enforce label %q", label)
+		}
+		if seen[label] {
+			return fmt.Errorf("duplicate enforce label %q", label)
+		}
+		seen[label] = true
+	}
+	return nil
+}
+
+// validateMetricNames checks that MetricNames renames known metrics to
+// valid, distinct names and that MetricHelp keys name known metrics.
+func (o *PrometheusLoggerOptions) validateMetricNames() error {
+	// The names do not depend on the metrics, so those of an empty logger
+	// suffice.
+	fields := (&PrometheusLogger{}).namedMetricFields()
+	used := make(map[string]string, len(o.MetricNames))
+	for key, name := range o.MetricNames {
+		if _, ok := fields[key]; !ok {
+			return fmt.Errorf("unknown metric %q in metric names", key)
+		}
+		if !metricNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid metric name %q for %q", name, key)
+		}
+		if other, ok := used[name]; ok {
+			return fmt.Errorf("metric name %q is used for both %q and %q", name, other, key)
+		}
+		used[name] = key
+	}
+	for key := range o.MetricHelp {
+		if _, ok := fields[key]; !ok {
+			return fmt.Errorf("unknown metric %q in metric help", key)
+		}
+	}
+	return nil
+}
+
+// validateAttributeLabels checks that the attribute labels have valid names
+// that are not used by other enforce labels.
+func (o *PrometheusLoggerOptions) validateAttributeLabels() error {
+	seen := make(map[string]bool, len(o.AttributeLabels))
+	for _, name := range o.AttributeLabels {
+		if !labelNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid attribute label name %q", name)
+		}
+		if _, ok := o.ContextLabels[name]; ok || seen[name] || slices.Contains(enforceLabelOrder, name) {
+			return fmt.Errorf("attribute label name %q is already used", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// validateContextLabels checks that the context labels have valid, unused
+// names, a context key and a set of allowed values.
+func (o *PrometheusLoggerOptions) validateContextLabels() error {
+	for name, key := range o.ContextLabels {
+		if !labelNameRegexp.MatchString(name) || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid context label name %q", name)
+		}
+		if slices.Contains(enforceLabelOrder, name) {
+			return fmt.Errorf("context label name %q is already used", name)
+		}
+		if key == nil {
+			return fmt.Errorf("context label %q has a nil context key", name)
+		}
+		if len(o.ContextLabelValues[name]) == 0 {
+			return fmt.Errorf("context label %q has no allowed values", name)
+		}
+	}
+	for name := range o.ContextLabelValues {
+		if _, ok := o.ContextLabels[name]; !ok {
+			return fmt.Errorf("allowed values for unknown context label %q", name)
+		}
+	}
+	return nil
+}
+
+// canonicalEnforceLabels returns a copy of labels in the canonical order, so
+// that the label set of the enforce metrics does not depend on the order
+// the labels were configured in.
+func canonicalEnforceLabels(labels []string) []string {
+	canonical := make([]string, 0, len(labels))
+	for _, label := range enforceLabelOrder {
+		if slices.Contains(labels, label) {
+			canonical = append(canonical, label)
+		}
+	}
+	return canonical
+}
+
+// metricHelp returns the help text of the metric with the given default
+// name, which is defaultHelp unless overridden by MetricHelp.
+func (o *PrometheusLoggerOptions) metricHelp(name, defaultHelp string) string {
+	if help, ok := o.MetricHelp[strings.TrimPrefix(name, "casbin_")]; ok && help != "" {
+		return help
+	}
+	if o.DurationUnit == Milliseconds && strings.HasSuffix(defaultHelp, " in seconds") {
+		return strings.TrimSuffix(defaultHelp, "seconds") + "milliseconds"
+	}
+	return defaultHelp
+}
+
+// metricName returns the name a metric is registered with.
+func (o *PrometheusLoggerOptions) metricName(name string, kind metricKind) string {
+	if custom, ok := o.MetricNames[strings.TrimPrefix(name, "casbin_")]; ok {
+		return custom
+	}
+	if o.Namespace != "" {
+		name = o.Namespace + strings.TrimPrefix(name, "casbin")
+	}
+	if o.DurationUnit == Milliseconds {
+		name = strings.Replace(name, "_seconds", "_milliseconds", 1)
+	}
+	if o.StrictNaming {
+		name = strictMetricName(name, kind)
+	}
+	return name
+}
+
+// durationScale returns the factor converting seconds to the duration unit.
+func (o *PrometheusLoggerOptions) durationScale() float64 {
+	if o.DurationUnit == Milliseconds {
+		return 1000
+	}
+	return 1
+}
+
+// durationBuckets returns buckets given in seconds in the duration unit.
+func (o *PrometheusLoggerOptions) durationBuckets(buckets []float64) []float64 {
+	scale := o.durationScale()
+	if scale == 1 {
+		return buckets
+	}
+	scaled := make([]float64, len(buckets))
+	for i, bucket := range buckets {
+		scaled[i] = bucket * scale
+	}
+	return scaled
+}
+
+// strictMetricName adjusts name to the naming conventions for its kind.
+func strictMetricName(name string, kind metricKind) string {
+	if kind == counterMetric {
+		if !strings.HasSuffix(name, "_total") {
+			name += "_total"
+		}
+		return name
+	}
+
+	for _, suffix := range []string{"_total", "_count", "_sum", "_bucket"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return name
+}
@@ -0,0 +1,364 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/client_golang/prometheus/testutil/promlint"
+)
+
+// recordAllMetrics records one sample into every metric of logger so that
+// all metric families are gathered.
+func recordAllMetrics(logger *PrometheusLogger) {
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventAddPolicy, RuleCount: 1})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventType("custom")})
+	logger.UpdatePolicyState("p", 1)
+	logger.UpdateRoleClosureSize("g", 1)
+	logger.LogError(errors.New("adapter error"))
+	if logger.enforceSeriesOverflow != nil {
+		logger.enforceSeriesOverflow.Inc()
+	}
+}
+
+func TestStrictNaming(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		StrictNaming:    true,
+		MaxSeries:       10,
+		EnforceByHour:   true,
+		EnforceWeighted: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	recordAllMetrics(logger)
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather returned error: %v", err)
+	}
+	problems, err := promlint.NewWithMetricFamilies(families).Lint()
+	if err != nil {
+		t.Fatalf("Lint returned error: %v", err)
+	}
+	for _, problem := range problems {
+		t.Errorf("%s: %s", problem.Metric, problem.Text)
+	}
+
+	names := logger.MetricNames()
+	for _, name := range []string{"casbin_policy_rules", "casbin_policy_state", "casbin_enforce_total"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Expected %s in metric names %v", name, names)
+		}
+	}
+}
+
+func TestStrictMetricName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		kind     metricKind
+		expected string
+	}{
+		{"casbin_enforce_total", counterMetric, "casbin_enforce_total"},
+		{"casbin_enforce", counterMetric, "casbin_enforce_total"},
+		{"casbin_policy_rules_count", gaugeMetric, "casbin_policy_rules"},
+		{"casbin_policy_rules_total", gaugeMetric, "casbin_policy_rules"},
+		{"casbin_enforce_duration_seconds", histogramMetric, "casbin_enforce_duration_seconds"},
+		{"casbin_enforce_duration_seconds_sum", histogramMetric, "casbin_enforce_duration_seconds"},
+	}
+
+	for _, tc := range testCases {
+		if got := strictMetricName(tc.name, tc.kind); got != tc.expected {
+			t.Errorf("strictMetricName(%q) = %q, expected %q", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestMetricHelp(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		MetricHelp: map[string]string{
+			"enforce_total":           "Enforce requests, see runbook AUTHZ-1",
+			"policy_operations_total": "Policy operations, see runbook AUTHZ-2",
+		},
+	})
+	defer logger.UnregisterFrom(registry)
+	recordAllMetrics(logger)
+
+	expected := `
+# HELP casbin_enforce_total Enforce requests, see runbook AUTHZ-1
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="false",domain="default"} 1
+# HELP casbin_policy_operations_total Policy operations, see runbook AUTHZ-2
+# TYPE casbin_policy_operations_total counter
+casbin_policy_operations_total{operation="addPolicy",success="true"} 1
+# HELP casbin_unknown_events_total Total number of active events with an unrecognized event type
+# TYPE casbin_unknown_events_total counter
+casbin_unknown_events_total{event_type="custom"} 1
+`
+	names := []string{"casbin_enforce_total", "casbin_policy_operations_total", "casbin_unknown_events_total"}
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), names...); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetricNames_Custom(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		MetricNames: map[string]string{
+			"enforce_total":           "authz_decisions_total",
+			"policy_operations_total": "authz:policy_changes",
+		},
+		// Custom names are used as given.
+		Namespace: "myapp",
+	})
+	defer logger.UnregisterFrom(registry)
+	recordAllMetrics(logger)
+
+	expected := `
+# HELP authz_decisions_total Total number of enforce requests
+# TYPE authz_decisions_total counter
+authz_decisions_total{allowed="false",domain="default"} 1
+# HELP authz:policy_changes Total number of policy operations
+# TYPE authz:policy_changes counter
+authz:policy_changes{operation="addPolicy",success="true"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "authz_decisions_total", "authz:policy_changes"); err != nil {
+		t.Error(err)
+	}
+	names := logger.MetricNames()
+	if !slices.Contains(names, "myapp_enforce_duration_seconds") || slices.Contains(names, "myapp_enforce_total") {
+		t.Errorf("Expected only the renamed metrics to change, got %v", names)
+	}
+}
+
+func TestDurationUnit_Milliseconds(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		DurationUnit:         Milliseconds,
+		EnforceDurationTotal: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	now := time.Now()
+	logger.now = func() time.Time { return now }
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Allowed: true, StartTime: now.Add(-30 * time.Millisecond)})
+
+	names := logger.MetricNames()
+	for _, name := range []string{"casbin_enforce_duration_milliseconds", "casbin_enforce_duration_milliseconds_total", "casbin_policy_operations_duration_milliseconds"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Expected %s in metric names %v", name, names)
+		}
+	}
+
+	expected := `
+# HELP casbin_enforce_duration_milliseconds_total Total duration of enforce requests in milliseconds
+# TYPE casbin_enforce_duration_milliseconds_total counter
+casbin_enforce_duration_milliseconds_total{allowed="true",domain="default"} 30
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_duration_milliseconds_total"); err != nil {
+		t.Error(err)
+	}
+
+	families, err := gatherCollectors(logger.enforceDuration)
+	if err != nil {
+		t.Fatalf("Gather returned error: %v", err)
+	}
+	histogram := families[0].GetMetric()[0].GetHistogram()
+	if sum := histogram.GetSampleSum(); sum != 30 {
+		t.Errorf("Expected a sum of 30 milliseconds, got %f", sum)
+	}
+	// The 30ms observation falls in the 50ms bucket, scaled from 0.05s.
+	for _, bucket := range histogram.GetBucket() {
+		if bucket.GetUpperBound() == 50 && bucket.GetCumulativeCount() != 1 {
+			t.Errorf("Expected the observation in the 50ms bucket, got %d", bucket.GetCumulativeCount())
+		}
+		if bucket.GetUpperBound() == 25 && bucket.GetCumulativeCount() != 0 {
+			t.Errorf("Expected no observation in the 25ms bucket, got %d", bucket.GetCumulativeCount())
+		}
+	}
+}
+
+func TestEnabledMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnabledMetrics: []string{"enforce_total"},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	recordAllMetrics(logger)
+	logger.UpdatePolicyStateBulk(map[string]int{"p": 2}, true)
+	logger.OnBeforeEvent(&LogEntry{IsActive: true, EventType: EventLoadPolicy})
+	logger.ResetEnforceMetrics()
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Allowed: true})
+
+	if names := logger.MetricNames(); !slices.Equal(names, []string{"casbin_enforce_total"}) {
+		t.Errorf("Expected only casbin_enforce_total, got %v", names)
+	}
+	if count, err := testutil.GatherAndCount(registry); err != nil || count != 1 {
+		t.Errorf("Expected 1 series in the registry, got %d (%v)", count, err)
+	}
+	if logger.GetPolicyOpsTotal() != nil {
+		t.Error("Expected the policy operations counter not to be created")
+	}
+}
+
+func TestDisablePolicyStateCount(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		DisablePolicyStateCount: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	logger.UpdatePolicyState("p", 3)
+	logger.UpdatePolicyStateBulk(map[string]int{"p": 2, "g": 1}, true)
+
+	if logger.GetPolicyStateCount() != nil {
+		t.Error("Expected the policy state gauge not to be created")
+	}
+	if slices.Contains(logger.MetricNames(), "casbin_policy_state_count") {
+		t.Error("Expected casbin_policy_state_count not to be registered")
+	}
+	if count, err := testutil.GatherAndCount(registry, "casbin_policy_state_count"); err != nil || count != 0 {
+		t.Errorf("Expected no policy state series, got %d (%v)", count, err)
+	}
+}
+
+func TestEnabledMetrics_OptionsKeepWorking(t *testing.T) {
+	subjectLabels := []string{LabelAllowed, LabelDomain, LabelSubject}
+	enforce := func(logger *PrometheusLogger, entry LogEntry) error {
+		entry.IsActive = true
+		entry.EventType = EventEnforce
+		entry.StartTime = time.Now()
+		return logger.OnAfterEvent(&entry)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		options *PrometheusLoggerOptions
+		check   func(t *testing.T, logger *PrometheusLogger)
+	}{
+		{
+			name:    "MaxSeries",
+			options: &PrometheusLoggerOptions{EnforceLabels: subjectLabels, MaxSeries: 1, EnabledMetrics: []string{"enforce_total"}},
+			check: func(t *testing.T, logger *PrometheusLogger) {
+				enforce(logger, LogEntry{Subject: "alice"})
+				enforce(logger, LogEntry{Subject: "bob"})
+				if value := testutil.ToFloat64(logger.GetEnforceTotal().WithLabelValues("false", overflowLabelValue, overflowLabelValue)); value != 1 {
+					t.Errorf("Expected 1 request in the overflow series, got %v", value)
+				}
+			},
+		},
+		{
+			name:    "CallbackTimeout",
+			options: &PrometheusLoggerOptions{CallbackTimeout: time.Millisecond, EnabledMetrics: []string{"enforce_total"}},
+			check: func(t *testing.T, logger *PrometheusLogger) {
+				release := make(chan struct{})
+				defer close(release)
+				logger.SetLogCallback(func(*LogEntry) error {
+					<-release
+					return nil
+				})
+				if err := enforce(logger, LogEntry{}); !errors.Is(err, ErrCallbackTimeout) {
+					t.Errorf("Expected ErrCallbackTimeout, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "EnforceWaitEval",
+			options: &PrometheusLoggerOptions{EnforceWaitEval: true, EnabledMetrics: []string{"enforce_wait_duration_seconds"}},
+			check: func(t *testing.T, logger *PrometheusLogger) {
+				enforce(logger, LogEntry{EvalStart: time.Now()})
+				if count := testutil.CollectAndCount(logger.enforceWaitDuration); count != 1 {
+					t.Errorf("Expected 1 wait duration series, got %d", count)
+				}
+			},
+		},
+		{
+			name:    "EnforceRetries",
+			options: &PrometheusLoggerOptions{EnforceRetries: true, EnabledMetrics: []string{"enforce_retries_total"}},
+			check: func(t *testing.T, logger *PrometheusLogger) {
+				enforce(logger, LogEntry{Retries: 2})
+				if value := testutil.ToFloat64(logger.enforceRetries.WithLabelValues("default")); value != 2 {
+					t.Errorf("Expected 2 retries, got %v", value)
+				}
+			},
+		},
+		{
+			name:    "RecoverCallbackPanics",
+			options: &PrometheusLoggerOptions{RecoverCallbackPanics: true, EnabledMetrics: []string{"enforce_total"}},
+			check: func(t *testing.T, logger *PrometheusLogger) {
+				logger.SetLogCallback(func(*LogEntry) error { panic("callback failed") })
+				if err := enforce(logger, LogEntry{}); !errors.Is(err, ErrCallbackPanic) {
+					t.Errorf("Expected ErrCallbackPanic, got %v", err)
+				}
+			},
+		},
+		{
+			name:    "StrictEntries",
+			options: &PrometheusLoggerOptions{EnforceLabels: subjectLabels, StrictEntries: true, EnabledMetrics: []string{"enforce_total"}},
+			check: func(t *testing.T, logger *PrometheusLogger) {
+				if err := enforce(logger, LogEntry{}); !errors.Is(err, ErrInvalidEntry) {
+					t.Errorf("Expected ErrInvalidEntry, got %v", err)
+				}
+				if count := testutil.CollectAndCount(logger.GetEnforceTotal()); count != 0 {
+					t.Errorf("Expected the invalid entry not to be recorded, got %d series", count)
+				}
+			},
+		},
+		{
+			name:    "StrictMode",
+			options: &PrometheusLoggerOptions{StrictMode: true, EnabledMetrics: []string{"enforce_total"}},
+			check: func(t *testing.T, logger *PrometheusLogger) {
+				enforce(logger, LogEntry{Allowed: true, Error: errors.New("matcher failed")})
+				if value := testutil.ToFloat64(logger.GetEnforceTotal().WithLabelValues("false", "default")); value != 1 {
+					t.Errorf("Expected the entry to be recorded as denied, got %v", value)
+				}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			logger := NewPrometheusLoggerWithOptions(registry, tc.options)
+			defer logger.UnregisterFrom(registry)
+			tc.check(t, logger)
+		})
+	}
+}
+
+func TestNamedMetricFields(t *testing.T) {
+	logger, err := newPrometheusLogger(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fields := logger.metricFields()
+	named := logger.namedMetricFields()
+	if len(named) != len(fields) {
+		t.Errorf("Expected %d named metric fields, got %d", len(fields), len(named))
+	}
+	for _, field := range named {
+		if !slices.Contains(fields, field) {
+			t.Errorf("Named metric field %T is missing from metricFields", field)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// idPathSegmentRegexp matches path segments that are numeric or UUIDs.
+var idPathSegmentRegexp = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// PathTemplateGrouper is an ObjectGrouper for objects that are URL paths. It
+// replaces the numeric and UUID segments of the path with "{id}", so that
+// "/users/42/posts/7" becomes "/users/{id}/posts/{id}". A query string is
+// dropped.
+func PathTemplateGrouper(object string) string {
+	path, _, _ := strings.Cut(object, "?")
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if idPathSegmentRegexp.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
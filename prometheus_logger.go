@@ -15,252 +15,2384 @@
 package prometheuslogger
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	dto "github.com/prometheus/client_model/go"
 )
 
+// Supported enforce metric label names.
+const (
+	LabelAllowed = "allowed"
+	LabelDomain  = "domain"
+	LabelSubject = "subject"
+	LabelObject  = "object"
+	LabelAction  = "action"
+	// LabelOutcome is "allow", "deny" or "error", an alternative to
+	// LabelAllowed that separates evaluation errors from clean denials.
+	LabelOutcome = "outcome"
+	// LabelDenyType is "default_deny" when no rule matched a denied request,
+	// "explicit_deny" when a deny rule matched, and "none" when allowed.
+	LabelDenyType = "deny_type"
+	// LabelEventType is the event type, separating EventEnforceEx from
+	// EventEnforce.
+	LabelEventType = "event_type"
+)
+
+// Values of the outcome label.
+const (
+	OutcomeAllow = "allow"
+	OutcomeDeny  = "deny"
+	OutcomeError = "error"
+)
+
+// noneAttributeLabelValue is the value of an attribute label whose attribute
+// is missing from the entry.
+const noneAttributeLabelValue = "none"
+
+// Values of the deny type label.
+const (
+	DenyTypeNone     = "none"
+	DenyTypeDefault  = "default_deny"
+	DenyTypeExplicit = "explicit_deny"
+)
+
+// Values of LogEntry.DenyCause and the deny cause label of
+// casbin_enforce_denies_total.
+const (
+	// DenyCauseNoRole is the cause of a denial of a subject without any
+	// role.
+	DenyCauseNoRole = "no_role"
+	// DenyCauseRoleDenied is the cause of a denial by a matching deny rule.
+	DenyCauseRoleDenied = "role_denied"
+	// DenyCauseNoPolicy is the cause of a denial of a subject with roles
+	// but no rule allowing the request.
+	DenyCauseNoPolicy = "no_policy"
+	// DenyCauseUnknown is recorded for a denial with an empty or
+	// unrecognized DenyCause.
+	DenyCauseUnknown = "unknown"
+)
+
+// RulesEvaluatedBuckets are the buckets of casbin_enforce_rules_evaluated.
+var RulesEvaluatedBuckets = []float64{1, 5, 10, 50, 100, 500, 1000, 5000, 10000}
+
+// PolicyReloadIntervalBuckets are the buckets of
+// casbin_policy_reload_interval_seconds, from one second to one day.
+var PolicyReloadIntervalBuckets = []float64{1, 5, 15, 60, 300, 900, 3600, 21600, 86400}
+
+// PolicyUpdateNetChangeBuckets are the buckets of
+// casbin_policy_update_net_change, for shrinking and growing updates.
+var PolicyUpdateNetChangeBuckets = []float64{-1000, -100, -10, -1, 0, 1, 10, 100, 1000}
+
+// ErrFactoryRegistry is returned by the methods that register metrics, such
+// as Reconfigure and AddObserver, on a logger created by
+// NewPrometheusLoggerWithFactory, because the factory does not expose the
+// registry its metrics are registered with.
+var ErrFactoryRegistry = errors.New("the registry of a logger created by a promauto factory is unknown")
+
+// DefaultEnforceLabels are the labels used by the enforce metrics unless
+// configured otherwise. Changing it does not change the defaults.
+var DefaultEnforceLabels = []string{LabelAllowed, LabelDomain}
+
+// defaultEnforceLabels are the labels the logger defaults to, kept apart from
+// DefaultEnforceLabels so that a caller modifying that slice cannot make the
+// fast path record values under the wrong labels.
+var defaultEnforceLabels = [...]string{LabelAllowed, LabelDomain}
+
 // PrometheusLogger is a logger that exports metrics to Prometheus.
 type PrometheusLogger struct {
 	enabledEventTypes map[EventType]bool
-	callback          func(entry *LogEntry) error
+	// callbackEventTypes restricts the callback to these event types if
+	// not empty.
+	callbackEventTypes map[EventType]bool
+	// callbackFilter restricts the callback to the entries it returns true
+	// for if set.
+	callbackFilter func(entry *LogEntry) bool
+	callback       func(entry *LogEntry) error
+
+	// gatherer gathers the registry the metrics are registered with. For a
+	// logger created by a promauto factory it is a private registry that
+	// only gathers, because the factory does not expose its registry.
+	gatherer prometheus.Gatherer
+	// fromFactory is set if the metrics were registered by a promauto
+	// factory, so the logger cannot register or unregister metrics itself.
+	fromFactory bool
+
+	// reconfigureMu is held for writing by Reconfigure while it replaces
+	// the configuration and metrics, and for reading by the methods that
+	// record into or reset the metrics.
+	reconfigureMu sync.RWMutex
+
+	enforceLabels []string
+	// attributeLabels are the enforce labels taken from LogEntry.Attributes.
+	attributeLabels map[string]bool
+	contextLabels   map[string]contextLabel
+	// defaultLabelsFastPath is set when the enforce labels are the
+	// defaultEnforceLabels and no series are tracked, so the label values
+	// can be passed without building a slice.
+	defaultLabelsFastPath bool
+	series                *seriesTracker
+	hourLocation          *time.Location
+	normalize             bool
+	sanitizeUTF8          bool
+	actionClassifier      func(action string) string
+	objectGrouper         func(object string) string
+	sloThresholds         map[string]time.Duration
+	callbackTimeout       time.Duration
+	expectedArgCount      int
+	onArgMismatch         func(entry *LogEntry)
+	policyPtype           bool
+	policyAdapter         bool
+	policySources         map[string]bool
+	pruneOnDisable        bool
+	// recoverPanics, strictEntries and strictMode are set by the options of
+	// the same names, independently of whether their counters are enabled.
+	recoverPanics bool
+	strictEntries bool
+	strictMode    bool
+	// sink receives the core enforce and policy operation measurements
+	// instead of the Prometheus metrics if set.
+	sink MetricSink
+	// durationScale converts seconds to the unit of the duration metrics.
+	durationScale float64
+	// countOnlyDuration is set if the enforce duration histogram has only
+	// the +Inf bucket.
+	countOnlyDuration bool
+	// metricName returns the name a metric is registered with.
+	metricName func(name string, kind metricKind) string
+
+	// policySuccessName, policySuccessValue and policyFailureValue are the
+	// name and values of the policy success label.
+	policySuccessName  string
+	policySuccessValue string
+	policyFailureValue string
+
+	// lastEnforce is the time of the last recorded enforce request in Unix
+	// nanoseconds, or zero if none was recorded.
+	lastEnforce atomic.Int64
+
+	// now returns the current time and can be replaced in tests.
+	now func() time.Time
+	// ctx is derived from BaseContext and cancelled by Close to stop the
+	// background goroutines.
+	ctx    context.Context
+	cancel context.CancelFunc
 
 	// Prometheus metrics
-	enforceDuration    *prometheus.HistogramVec
-	enforceTotal       *prometheus.CounterVec
-	policyOpsTotal     *prometheus.CounterVec
-	policyOpsDuration  *prometheus.HistogramVec
-	policyRulesCount   *prometheus.GaugeVec
+	enforceDuration   *prometheus.HistogramVec
+	enforceTotal      *prometheus.CounterVec
+	policyOpsTotal    *prometheus.CounterVec
+	policyOpsDuration *prometheus.HistogramVec
+	policyRulesCount  *prometheus.GaugeVec
+	policyStateCount  *prometheus.GaugeVec
+	unknownEvents     *prometheus.CounterVec
+	roleClosureSize   *prometheus.GaugeVec
+	internalErrors    *prometheus.CounterVec
+	eventsFiltered    *prometheus.CounterVec
+	policyUpdateNet   *prometheus.HistogramVec
+	modelLoadDuration *prometheus.HistogramVec
+	modelLoadTotal    *prometheus.CounterVec
+
+	// policyStateMu serializes policy state updates; policyStatePtypes holds
+	// the ptypes that currently have a policy state series.
+	policyStateMu     sync.Mutex
+	policyStatePtypes map[string]bool
+
+	// Optional metrics, nil unless enabled by options
+	enforceSeriesOverflow prometheus.Counter
+	loggerSeries          prometheus.Gauge
+	enforceDurationTotal  *prometheus.CounterVec
+	enforceByHour         *prometheus.CounterVec
+	enforceWeighted       *prometheus.CounterVec
+	enforceSLOViolations  *prometheus.CounterVec
+	callbackPanics        prometheus.Counter
+	callbackTimeouts      prometheus.Counter
+	callbackDuration      prometheus.Histogram
+	consecutiveDenies     *prometheus.GaugeVec
+	invalidEntries        prometheus.Counter
+	entryInconsistencies  prometheus.Counter
+	enforceRetries        *prometheus.CounterVec
+	enforceWaitDuration   *prometheus.HistogramVec
+	enforceEvalDuration   *prometheus.HistogramVec
+	enforceCPUDuration    *prometheus.HistogramVec
+	enforceMaxRetries     prometheus.Gauge
+	enforceRulesEvaluated *prometheus.HistogramVec
+	policyReloadInterval  prometheus.Histogram
+	policyOpErrors        *prometheus.CounterVec
+	enforceLatencyEWMA    *prometheus.GaugeVec
+	enforceErrorRate      *errorRateCollector
+	distinctSubjects      *distinctSubjectsCollector
+	enforceAllowRatio     *allowRatioCollector
+	enforceArgMismatch    prometheus.Counter
+	enforceDenies         *prometheus.CounterVec
+	enforceCacheHits      *prometheus.CounterVec
+	enforceCacheMisses    *prometheus.CounterVec
+	domainConcurrency     *prometheus.GaugeVec
+	domainConcurrencyMax  *prometheus.GaugeVec
+
+	// decisions holds the most recent enforce entries if DecisionLogSize is
+	// set.
+	decisions *decisionLog
+	// observers are the histograms added with AddObserver, which appends to
+	// them holding reconfigureMu. Reconfigure keeps them.
+	observers []observer
+
+	// lastLoadMu guards lastLoad, the time of the last policy load.
+	lastLoadMu sync.Mutex
+	lastLoad   time.Time
+
+	// maxRetriesMu guards maxRetries, the value of enforceMaxRetries.
+	maxRetriesMu sync.Mutex
+	maxRetries   int
+
+	// latencyEWMAMu guards latencyEWMA, the values of enforceLatencyEWMA by
+	// domain.
+	latencyEWMAMu    sync.Mutex
+	latencyEWMA      map[string]float64
+	latencyEWMAAlpha float64
+
+	// concurrencyMu guards concurrency and concurrencyMax, the in-flight
+	// enforce requests by domain and their highest number.
+	concurrencyMu  sync.Mutex
+	concurrency    map[string]int
+	concurrencyMax map[string]int
 }
 
-// NewPrometheusLogger creates a new PrometheusLogger with default metrics.
+// NewPrometheusLogger creates a new PrometheusLogger with default metrics,
+// registered with the default Prometheus registry. If another logger already
+// registered the metrics there, they are shared instead of causing a panic.
 func NewPrometheusLogger() *PrometheusLogger {
+	logger, _ := newPrometheusLogger(nil)
+
+	// Register all metrics, adopting those already registered
+	logger.registerDefault()
+	logger.gatherer = prometheus.DefaultGatherer
+
+	return logger
+}
+
+var (
+	defaultLoggerOnce sync.Once
+	defaultLogger     *PrometheusLogger
+)
+
+// DefaultLogger returns the shared PrometheusLogger registered with the default
+// Prometheus registry, creating it on first use. It is safe to call from
+// concurrent goroutines. Use NewPrometheusLogger for a separate instance.
+func DefaultLogger() *PrometheusLogger {
+	defaultLoggerOnce.Do(func() {
+		defaultLogger = NewPrometheusLogger()
+	})
+	return defaultLogger
+}
+
+// NewPrometheusLoggerWithRegistry creates a new PrometheusLogger with a custom registry.
+func NewPrometheusLoggerWithRegistry(registry *prometheus.Registry) *PrometheusLogger {
+	logger, _ := newPrometheusLogger(nil)
+
+	// Register all metrics with the provided registry
+	registry.MustRegister(logger.collectors()...)
+	logger.gatherer = registry
+
+	return logger
+}
+
+// NewPrometheusLoggerWithOptions creates a new PrometheusLogger configured by options.
+// If registry is nil, the metrics are registered with the default Prometheus
+// registry, adopting metrics already registered there by another logger.
+// It panics if the options are invalid or the metrics cannot be registered.
+func NewPrometheusLoggerWithOptions(registry *prometheus.Registry, options *PrometheusLoggerOptions) *PrometheusLogger {
+	logger, err := newPrometheusLogger(options)
+	if err != nil {
+		panic(err)
+	}
+
+	if registry == nil {
+		logger.registerDefault()
+		logger.gatherer = prometheus.DefaultGatherer
+	} else {
+		registry.MustRegister(logger.collectors()...)
+		logger.gatherer = registry
+	}
+
+	return logger
+}
+
+// NewPrometheusLoggerWithFactory creates a new PrometheusLogger whose metrics
+// are created, and registered, by a promauto factory such as
+// promauto.With(registry). It panics if the metrics cannot be registered.
+// The factory does not expose its registry, so HandlerWithTimeout serves only
+// the metrics of the logger, and the methods that register metrics, such as
+// Reconfigure and AddObserver, return ErrFactoryRegistry. Use UnregisterFrom
+// with the factory's registry to unregister the metrics.
+func NewPrometheusLoggerWithFactory(factory promauto.Factory) *PrometheusLogger {
+	logger, _ := newPrometheusLoggerWithFactory(factory, nil)
+
+	// The private registry only gathers the metrics for HandlerWithTimeout.
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(logger.collectors()...)
+	logger.gatherer = registry
+	logger.fromFactory = true
+
+	return logger
+}
+
+// CheckRegistry returns the sorted names of the metrics a logger configured by
+// options would register that already exist in registry, so that a caller can
+// pick another configuration before registering. A nil registry checks the
+// default Prometheus registry. The existing metrics are found by gathering, so
+// metrics without any series yet are not reported. It panics if the options
+// are invalid, like NewPrometheusLoggerWithOptions.
+func CheckRegistry(registry *prometheus.Registry, options *PrometheusLoggerOptions) []string {
+	logger, err := newPrometheusLogger(options)
+	if err != nil {
+		panic(err)
+	}
+	logger.Close()
+
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if registry != nil {
+		gatherer = registry
+	}
+	// Gather returns the families it could collect along with any error.
+	families, _ := gatherer.Gather()
+
+	existing := make(map[string]bool, len(families))
+	for _, family := range families {
+		existing[family.GetName()] = true
+	}
+
+	var collisions []string
+	for _, name := range logger.MetricNames() {
+		if existing[name] {
+			collisions = append(collisions, name)
+		}
+	}
+	return collisions
+}
+
+// newPrometheusLogger creates a PrometheusLogger without registering its metrics.
+// A nil options value selects the defaults.
+func newPrometheusLogger(options *PrometheusLoggerOptions) (*PrometheusLogger, error) {
+	// A factory without a registerer creates metrics without registering them.
+	return newPrometheusLoggerWithFactory(promauto.With(nil), options)
+}
+
+// newPrometheusLoggerWithFactory creates a PrometheusLogger whose metrics are
+// created, and registered if it has a registerer, by factory.
+func newPrometheusLoggerWithFactory(factory promauto.Factory, options *PrometheusLoggerOptions) (*PrometheusLogger, error) {
+	if options == nil {
+		options = &PrometheusLoggerOptions{}
+	}
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	enforceLabels := slices.Clone(defaultEnforceLabels[:])
+	if options.EnforceLabels != nil {
+		enforceLabels = canonicalEnforceLabels(options.EnforceLabels)
+	}
+	var attributeLabels map[string]bool
+	if len(options.AttributeLabels) > 0 {
+		attributeLabels = make(map[string]bool, len(options.AttributeLabels))
+		for _, label := range options.AttributeLabels {
+			attributeLabels[label] = true
+		}
+		enforceLabels = append(slices.Clone(enforceLabels), slices.Sorted(maps.Keys(attributeLabels))...)
+	}
+	contextLabels := newContextLabels(options)
+	if len(contextLabels) > 0 {
+		enforceLabels = append(slices.Clone(enforceLabels), slices.Sorted(maps.Keys(contextLabels))...)
+	}
+
 	logger := &PrometheusLogger{
 		enabledEventTypes: make(map[EventType]bool),
-		enforceDuration: prometheus.NewHistogramVec(
+		enforceLabels:     enforceLabels,
+		attributeLabels:   attributeLabels,
+		contextLabels:     contextLabels,
+		normalize:         options.NormalizeLabelValues,
+		sanitizeUTF8:      options.SanitizeUTF8,
+		actionClassifier:  options.ActionClassifier,
+		objectGrouper:     options.ObjectGrouper,
+		sink:              options.MetricSink,
+		policyPtype:       options.PolicyPtypeLabel,
+		policyAdapter:     options.PolicyAdapterLabel,
+		policySources:     policySources(options),
+		pruneOnDisable:    options.PruneOnDisable,
+		recoverPanics:     options.RecoverCallbackPanics,
+		strictEntries:     options.StrictEntries,
+		strictMode:        options.StrictMode,
+		durationScale:     options.durationScale(),
+		countOnlyDuration: options.CountOnlyDuration,
+		now:               time.Now,
+		policyOpsTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_policy_operations_total", counterMetric),
+				Help: options.metricHelp("casbin_policy_operations_total", "Total number of policy operations"),
+			},
+			policyOpsTotalLabels(options),
+		),
+		policyOpsDuration: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Name:    "casbin_enforce_duration_seconds",
-				Help:    "Duration of enforce requests in seconds",
-				Buckets: prometheus.DefBuckets,
+				Name:    options.metricName("casbin_policy_operations_duration_seconds", histogramMetric),
+				Help:    options.metricHelp("casbin_policy_operations_duration_seconds", "Duration of policy operations in seconds"),
+				Buckets: options.durationBuckets(prometheus.DefBuckets),
+			},
+			policyOpsDurationLabels(options),
+		),
+		policyRulesCount: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: options.metricName("casbin_policy_rules_count", gaugeMetric),
+				Help: options.metricHelp("casbin_policy_rules_count", "Number of policy rules affected by operations"),
+			},
+			[]string{"operation"},
+		),
+		policyStateCount: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: options.metricName("casbin_policy_state_count", gaugeMetric),
+				Help: options.metricHelp("casbin_policy_state_count", "Current number of policy rules by ptype"),
+			},
+			[]string{"ptype"},
+		),
+		unknownEvents: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_unknown_events_total", counterMetric),
+				Help: options.metricHelp("casbin_unknown_events_total", "Total number of active events with an unrecognized event type"),
 			},
-			[]string{"allowed", "domain"},
+			[]string{"event_type"},
 		),
-		enforceTotal: prometheus.NewCounterVec(
+		roleClosureSize: factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: options.metricName("casbin_role_closure_size", gaugeMetric),
+				Help: options.metricHelp("casbin_role_closure_size", "Number of effective role edges after transitive expansion by ptype"),
+			},
+			[]string{"ptype"},
+		),
+		internalErrors: factory.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "casbin_enforce_total",
-				Help: "Total number of enforce requests",
+				Name: options.metricName("casbin_internal_errors_total", counterMetric),
+				Help: options.metricHelp("casbin_internal_errors_total", "Total number of internal casbin errors reported through LogError by category"),
 			},
-			[]string{"allowed", "domain"},
+			[]string{"category"},
 		),
-		policyOpsTotal: prometheus.NewCounterVec(
+		eventsFiltered: factory.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "casbin_policy_operations_total",
-				Help: "Total number of policy operations",
+				Name: options.metricName("casbin_events_filtered_total", counterMetric),
+				Help: options.metricHelp("casbin_events_filtered_total", "Total number of events not logged because their event type is disabled"),
 			},
-			[]string{"operation", "success"},
+			[]string{"event_type"},
 		),
-		policyOpsDuration: prometheus.NewHistogramVec(
+		policyUpdateNet: factory.NewHistogramVec(
 			prometheus.HistogramOpts{
-				Name:    "casbin_policy_operations_duration_seconds",
-				Help:    "Duration of policy operations in seconds",
-				Buckets: prometheus.DefBuckets,
+				Name:    options.metricName("casbin_policy_update_net_change", histogramMetric),
+				Help:    options.metricHelp("casbin_policy_update_net_change", "Change in the number of policy rules made by update operations"),
+				Buckets: PolicyUpdateNetChangeBuckets,
 			},
 			[]string{"operation"},
 		),
-		policyRulesCount: prometheus.NewGaugeVec(
-			prometheus.GaugeOpts{
-				Name: "casbin_policy_rules_count",
-				Help: "Number of policy rules affected by operations",
+		modelLoadDuration: factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    options.metricName("casbin_model_load_duration_seconds", histogramMetric),
+				Help:    options.metricHelp("casbin_model_load_duration_seconds", "Duration of model loads in seconds"),
+				Buckets: options.durationBuckets(prometheus.DefBuckets),
 			},
-			[]string{"operation"},
+			// A vector exports nothing until the first model load is
+			// recorded.
+			[]string{"success"},
+		),
+		modelLoadTotal: factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_model_load_total", counterMetric),
+				Help: options.metricHelp("casbin_model_load_total", "Total number of model loads"),
+			},
+			[]string{"success"},
 		),
+		policyStatePtypes: make(map[string]bool),
 	}
+	logger.policySuccessName, logger.policySuccessValue, logger.policyFailureValue = options.policySuccessLabel()
+	// Copy the naming options, so later changes to options do not rename
+	// the metrics referenced by AlertingRules.
+	logger.metricName = (&PrometheusLoggerOptions{
+		Namespace:    options.Namespace,
+		DurationUnit: options.DurationUnit,
+		StrictNaming: options.StrictNaming,
+		MetricNames:  maps.Clone(options.MetricNames),
+	}).metricName
+	logger.enforceDuration, logger.enforceTotal = newEnforceMetrics(factory, options, logger.enforceLabels)
 
-	// Register all metrics
-	prometheus.MustRegister(
-		logger.enforceDuration,
-		logger.enforceTotal,
-		logger.policyOpsTotal,
-		logger.policyOpsDuration,
-		logger.policyRulesCount,
-	)
+	if options.MaxSeries > 0 || options.IdleSeriesTTL > 0 {
+		logger.loggerSeries = factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: options.metricName("casbin_logger_series_total", gaugeMetric),
+				Help: options.metricHelp("casbin_logger_series_total", "Current number of enforce label combinations tracked by the logger"),
+			},
+		)
+		logger.series = newSeriesTracker(options.MaxSeries, logger.loggerSeries)
+	}
+	logger.defaultLabelsFastPath = logger.series == nil && slices.Equal(logger.enforceLabels, defaultEnforceLabels[:])
+	if options.EnforceDurationTotal {
+		logger.enforceDurationTotal = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_enforce_duration_seconds_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_duration_seconds_total", "Total duration of enforce requests in seconds"),
+			},
+			logger.enforceLabels,
+		)
+	}
+	if options.MaxSeries > 0 {
+		logger.enforceSeriesOverflow = factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_enforce_series_overflow_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_series_overflow_total", "Total number of enforce requests recorded in the overflow series"),
+			},
+		)
+	}
 
-	return logger
-}
+	if options.EnforceByHour {
+		logger.hourLocation = time.UTC
+		if options.EnforceByHourLocation != nil {
+			logger.hourLocation = options.EnforceByHourLocation
+		}
+		logger.enforceByHour = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_enforce_by_hour_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_by_hour_total", "Total number of enforce requests by hour of day"),
+			},
+			[]string{"hour", "allowed"},
+		)
+	}
 
-// NewPrometheusLoggerWithRegistry creates a new PrometheusLogger with a custom registry.
-func NewPrometheusLoggerWithRegistry(registry *prometheus.Registry) *PrometheusLogger {
-	logger := &PrometheusLogger{
-		enabledEventTypes: make(map[EventType]bool),
-		enforceDuration: prometheus.NewHistogramVec(
-			prometheus.HistogramOpts{
-				Name:    "casbin_enforce_duration_seconds",
-				Help:    "Duration of enforce requests in seconds",
-				Buckets: prometheus.DefBuckets,
+	if options.EnforceWeighted {
+		logger.enforceWeighted = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_enforce_weighted_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_weighted_total", "Total weight of enforce requests"),
 			},
-			[]string{"allowed", "domain"},
-		),
-		enforceTotal: prometheus.NewCounterVec(
+			[]string{"domain"},
+		)
+	}
+
+	if len(options.SLOThresholds) > 0 {
+		logger.sloThresholds = make(map[string]time.Duration, len(options.SLOThresholds))
+		for action, threshold := range options.SLOThresholds {
+			logger.sloThresholds[action] = threshold
+		}
+		logger.enforceSLOViolations = factory.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "casbin_enforce_total",
-				Help: "Total number of enforce requests",
+				Name: options.metricName("casbin_enforce_slo_violations_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_slo_violations_total", "Total number of enforce requests exceeding the latency objective of their action"),
 			},
-			[]string{"allowed", "domain"},
-		),
-		policyOpsTotal: prometheus.NewCounterVec(
+			[]string{"action"},
+		)
+	}
+
+	if options.EnforceRulesEvaluated {
+		logger.enforceRulesEvaluated = factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    options.metricName("casbin_enforce_rules_evaluated", histogramMetric),
+				Help:    options.metricHelp("casbin_enforce_rules_evaluated", "Number of policy rules evaluated per enforce request"),
+				Buckets: RulesEvaluatedBuckets,
+			},
+			[]string{"domain"},
+		)
+	}
+
+	if options.EnforceWaitEval {
+		logger.enforceWaitDuration = factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    options.metricName("casbin_enforce_wait_duration_seconds", histogramMetric),
+				Help:    options.metricHelp("casbin_enforce_wait_duration_seconds", "Time enforce requests waited before evaluation in seconds"),
+				Buckets: options.durationBuckets(prometheus.DefBuckets),
+			},
+			[]string{"domain"},
+		)
+		logger.enforceEvalDuration = factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    options.metricName("casbin_enforce_eval_duration_seconds", histogramMetric),
+				Help:    options.metricHelp("casbin_enforce_eval_duration_seconds", "Time spent evaluating enforce requests in seconds"),
+				Buckets: options.durationBuckets(prometheus.DefBuckets),
+			},
+			[]string{"domain"},
+		)
+	}
+
+	if options.EnforceCPU {
+		logger.enforceCPUDuration = factory.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    options.metricName("casbin_enforce_cpu_seconds", histogramMetric),
+				Help:    options.metricHelp("casbin_enforce_cpu_seconds", "CPU time of enforce requests in seconds"),
+				Buckets: options.durationBuckets(prometheus.DefBuckets),
+			},
+			[]string{"domain"},
+		)
+	}
+
+	if options.EnforceRetries {
+		logger.enforceRetries = factory.NewCounterVec(
 			prometheus.CounterOpts{
-				Name: "casbin_policy_operations_total",
-				Help: "Total number of policy operations",
+				Name: options.metricName("casbin_enforce_retries_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_retries_total", "Total number of enforce request retries"),
 			},
-			[]string{"operation", "success"},
-		),
-		policyOpsDuration: prometheus.NewHistogramVec(
+			[]string{"domain"},
+		)
+		logger.enforceMaxRetries = factory.NewGauge(
+			prometheus.GaugeOpts{
+				Name: options.metricName("casbin_enforce_max_retries", gaugeMetric),
+				Help: options.metricHelp("casbin_enforce_max_retries", "Largest number of retries observed for one enforce request"),
+			},
+		)
+	}
+
+	if options.PolicyReloadInterval {
+		logger.policyReloadInterval = factory.NewHistogram(
 			prometheus.HistogramOpts{
-				Name:    "casbin_policy_operations_duration_seconds",
-				Help:    "Duration of policy operations in seconds",
-				Buckets: prometheus.DefBuckets,
+				Name:    options.metricName("casbin_policy_reload_interval_seconds", histogramMetric),
+				Help:    options.metricHelp("casbin_policy_reload_interval_seconds", "Time between consecutive policy loads in seconds"),
+				Buckets: options.durationBuckets(PolicyReloadIntervalBuckets),
 			},
-			[]string{"operation"},
-		),
-		policyRulesCount: prometheus.NewGaugeVec(
+		)
+	}
+
+	if options.PolicyOperationErrors {
+		logger.policyOpErrors = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_policy_operation_errors_total", counterMetric),
+				Help: options.metricHelp("casbin_policy_operation_errors_total", "Total number of failed policy operations by error category"),
+			},
+			[]string{"operation", "error_category"},
+		)
+	}
+
+	if options.RecoverCallbackPanics {
+		logger.callbackPanics = factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_callback_panics_total", counterMetric),
+				Help: options.metricHelp("casbin_callback_panics_total", "Total number of panics recovered from the log callback"),
+			},
+		)
+	}
+
+	if options.EnforceConsecutiveDenies {
+		logger.consecutiveDenies = factory.NewGaugeVec(
 			prometheus.GaugeOpts{
-				Name: "casbin_policy_rules_count",
-				Help: "Number of policy rules affected by operations",
+				Name: options.metricName("casbin_enforce_consecutive_denies", gaugeMetric),
+				Help: options.metricHelp("casbin_enforce_consecutive_denies", "Current number of consecutive denied enforce requests by domain"),
 			},
-			[]string{"operation"},
-		),
+			[]string{"domain"},
+		)
 	}
 
-	// Register all metrics with the provided registry
-	registry.MustRegister(
-		logger.enforceDuration,
-		logger.enforceTotal,
-		logger.policyOpsTotal,
-		logger.policyOpsDuration,
-		logger.policyRulesCount,
-	)
+	if options.EnforceLatencyEWMAAlpha > 0 {
+		logger.latencyEWMAAlpha = options.EnforceLatencyEWMAAlpha
+		logger.latencyEWMA = make(map[string]float64)
+		logger.enforceLatencyEWMA = factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: options.metricName("casbin_enforce_latency_ewma_seconds", gaugeMetric),
+				Help: options.metricHelp("casbin_enforce_latency_ewma_seconds", "Exponentially weighted moving average of the enforce duration by domain in seconds"),
+			},
+			[]string{"domain"},
+		)
+	}
 
-	return logger
-}
+	if options.ExpectedArgCount > 0 {
+		logger.expectedArgCount = options.ExpectedArgCount
+		logger.onArgMismatch = options.OnArgMismatch
+		logger.enforceArgMismatch = factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_enforce_arg_mismatch_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_arg_mismatch_total", "Total number of enforce requests with an unexpected number of request arguments"),
+			},
+		)
+	}
 
-// SetEventTypes configures which event types should be logged.
-func (p *PrometheusLogger) SetEventTypes(eventTypes []EventType) error {
-	p.enabledEventTypes = make(map[EventType]bool)
-	for _, eventType := range eventTypes {
-		p.enabledEventTypes[eventType] = true
+	if options.EnforceCache {
+		logger.enforceCacheHits = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_enforce_cache_hits_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_cache_hits_total", "Total number of enforce requests served from the decision cache"),
+			},
+			[]string{"domain"},
+		)
+		logger.enforceCacheMisses = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_enforce_cache_misses_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_cache_misses_total", "Total number of enforce requests not found in the decision cache"),
+			},
+			[]string{"domain"},
+		)
 	}
-	return nil
-}
 
-// OnBeforeEvent is called before an event occurs.
-func (p *PrometheusLogger) OnBeforeEvent(entry *LogEntry) error {
-	if len(p.enabledEventTypes) > 0 && !p.enabledEventTypes[entry.EventType] {
-		entry.IsActive = false
-		return nil
+	if options.EnforceDenyCause {
+		logger.enforceDenies = factory.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_enforce_denies_total", counterMetric),
+				Help: options.metricHelp("casbin_enforce_denies_total", "Total number of denied enforce requests by cause"),
+			},
+			[]string{"domain", "deny_cause"},
+		)
 	}
 
-	entry.IsActive = true
-	entry.StartTime = time.Now()
-	return nil
-}
+	if options.EnforceDomainConcurrency {
+		logger.concurrency = make(map[string]int)
+		logger.concurrencyMax = make(map[string]int)
+		logger.domainConcurrency = factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: options.metricName("casbin_enforce_domain_concurrency", gaugeMetric),
+				Help: options.metricHelp("casbin_enforce_domain_concurrency", "Current number of in-flight enforce requests by domain"),
+			},
+			[]string{"domain"},
+		)
+		logger.domainConcurrencyMax = factory.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: options.metricName("casbin_enforce_domain_concurrency_max", gaugeMetric),
+				Help: options.metricHelp("casbin_enforce_domain_concurrency_max", "Highest number of in-flight enforce requests by domain"),
+			},
+			[]string{"domain"},
+		)
+	}
 
-// OnAfterEvent is called after an event completes and records metrics.
-func (p *PrometheusLogger) OnAfterEvent(entry *LogEntry) error {
-	if !entry.IsActive {
-		return nil
+	if options.EnforceErrorRateWindow > 0 {
+		logger.enforceErrorRate = newErrorRateCollector(options, func() time.Time { return logger.now() })
 	}
 
-	entry.EndTime = time.Now()
-	entry.Duration = entry.EndTime.Sub(entry.StartTime)
+	if options.EnforceDistinctSubjects {
+		logger.distinctSubjects = newDistinctSubjectsCollector(options)
+	}
 
-	// Record metrics based on event type
-	switch entry.EventType {
-	case EventEnforce:
-		p.recordEnforceMetrics(entry)
-	case EventAddPolicy, EventRemovePolicy, EventLoadPolicy, EventSavePolicy:
-		p.recordPolicyMetrics(entry)
+	if options.EnforceAllowRatio {
+		logger.enforceAllowRatio = newAllowRatioCollector(options)
 	}
 
-	// Call custom callback if set
-	if p.callback != nil {
-		return p.callback(entry)
+	if options.DecisionLogSize > 0 {
+		logger.decisions = newDecisionLog(options.DecisionLogSize)
 	}
 
-	return nil
-}
+	if options.CallbackTimeout > 0 {
+		logger.callbackTimeout = options.CallbackTimeout
+		logger.callbackTimeouts = factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_callback_timeouts_total", counterMetric),
+				Help: options.metricHelp("casbin_callback_timeouts_total", "Total number of log callbacks that did not return within the timeout"),
+			},
+		)
+	}
 
-// SetLogCallback sets a custom callback function for log entries.
-func (p *PrometheusLogger) SetLogCallback(callback func(entry *LogEntry) error) error {
-	p.callback = callback
-	return nil
-}
+	if options.CallbackDuration {
+		logger.callbackDuration = factory.NewHistogram(
+			prometheus.HistogramOpts{
+				Name:    options.metricName("casbin_callback_duration_seconds", histogramMetric),
+				Help:    options.metricHelp("casbin_callback_duration_seconds", "Duration of log callbacks in seconds"),
+				Buckets: options.durationBuckets(prometheus.DefBuckets),
+			},
+		)
+	}
 
-// recordEnforceMetrics records metrics for enforce events.
-func (p *PrometheusLogger) recordEnforceMetrics(entry *LogEntry) {
-	domain := entry.Domain
-	if domain == "" {
-		domain = "default"
+	if options.StrictEntries {
+		logger.invalidEntries = factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_invalid_entries_total", counterMetric),
+				Help: options.metricHelp("casbin_invalid_entries_total", "Total number of log entries skipped because they failed validation"),
+			},
+		)
 	}
 
-	allowed := "false"
-	if entry.Allowed {
-		allowed = "true"
+	if options.StrictMode {
+		logger.entryInconsistencies = factory.NewCounter(
+			prometheus.CounterOpts{
+				Name: options.metricName("casbin_entry_inconsistencies_total", counterMetric),
+				Help: options.metricHelp("casbin_entry_inconsistencies_total", "Total number of enforce entries that were allowed despite an error"),
+			},
+		)
 	}
 
-	p.enforceDuration.WithLabelValues(allowed, domain).Observe(entry.Duration.Seconds())
-	p.enforceTotal.WithLabelValues(allowed, domain).Inc()
-}
+	if options.EnabledMetrics != nil {
+		if err := logger.keepMetrics(options.EnabledMetrics); err != nil {
+			return nil, err
+		}
+		if logger.series != nil {
+			logger.series.size = logger.loggerSeries
+		}
+	}
 
-// recordPolicyMetrics records metrics for policy operation events.
-func (p *PrometheusLogger) recordPolicyMetrics(entry *LogEntry) {
-	operation := string(entry.EventType)
-	success := "true"
-	if entry.Error != nil {
-		success = "false"
+	if options.DisablePolicyStateCount {
+		logger.policyStateCount = nil
 	}
 
-	p.policyOpsTotal.WithLabelValues(operation, success).Inc()
-	p.policyOpsDuration.WithLabelValues(operation).Observe(entry.Duration.Seconds())
+	baseContext := options.BaseContext
+	if baseContext == nil {
+		baseContext = context.Background()
+	}
+	logger.ctx, logger.cancel = context.WithCancel(baseContext)
 
-	if entry.RuleCount > 0 {
-		p.policyRulesCount.WithLabelValues(operation).Set(float64(entry.RuleCount))
+	if options.IdleSeriesTTL > 0 {
+		go logger.runIdleSweeper(logger.ctx, options.IdleSeriesTTL)
 	}
+
+	return logger, nil
 }
 
-// Unregister unregisters all metrics from the default Prometheus registry.
-// This is useful for testing or when you need to recreate the logger.
-func (p *PrometheusLogger) Unregister() {
-	prometheus.Unregister(p.enforceDuration)
-	prometheus.Unregister(p.enforceTotal)
-	prometheus.Unregister(p.policyOpsTotal)
-	prometheus.Unregister(p.policyOpsDuration)
-	prometheus.Unregister(p.policyRulesCount)
+// keepMetrics clears the metric fields not named in enabled, so they are
+// neither registered nor recorded.
+func (p *PrometheusLogger) keepMetrics(enabled []string) error {
+	fields := p.namedMetricFields()
+	for _, name := range enabled {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("unknown metric %q in enabled metrics", name)
+		}
+	}
+	for name, field := range fields {
+		if !slices.Contains(enabled, name) {
+			storeCollector(field, nil)
+		}
+	}
+	return nil
 }
 
-// UnregisterFrom unregisters all metrics from a specific Prometheus registry.
-func (p *PrometheusLogger) UnregisterFrom(registry *prometheus.Registry) bool {
-	result := true
-	result = registry.Unregister(p.enforceDuration) && result
-	result = registry.Unregister(p.enforceTotal) && result
-	result = registry.Unregister(p.policyOpsTotal) && result
-	result = registry.Unregister(p.policyOpsDuration) && result
-	result = registry.Unregister(p.policyRulesCount) && result
-	return result
+// policyOpsTotalLabels returns the labels of the policy operations counter.
+func policyOpsTotalLabels(options *PrometheusLoggerOptions) []string {
+	name, _, _ := options.policySuccessLabel()
+	labels := []string{"operation", name}
+	if options.PolicyPtypeLabel {
+		labels = append(labels, "ptype")
+	}
+	if options.PolicyAdapterLabel {
+		labels = append(labels, "adapter")
+	}
+	if options.PolicySources != nil {
+		labels = append(labels, "source")
+	}
+	return labels
 }
 
-// GetEnforceDuration returns the enforce duration histogram metric.
-func (p *PrometheusLogger) GetEnforceDuration() *prometheus.HistogramVec {
-	return p.enforceDuration
+// policyOpsDurationLabels returns the labels of the policy operations
+// duration histogram.
+func policyOpsDurationLabels(options *PrometheusLoggerOptions) []string {
+	labels := []string{"operation"}
+	if options.PolicyAdapterLabel {
+		labels = append(labels, "adapter")
+	}
+	if options.PolicySources != nil {
+		labels = append(labels, "source")
+	}
+	return labels
 }
 
-// GetEnforceTotal returns the enforce total counter metric.
-func (p *PrometheusLogger) GetEnforceTotal() *prometheus.CounterVec {
-	return p.enforceTotal
+// policySources returns the set of PolicySources, or nil if the source label
+// is disabled.
+func policySources(options *PrometheusLoggerOptions) map[string]bool {
+	if options.PolicySources == nil {
+		return nil
+	}
+	sources := make(map[string]bool, len(options.PolicySources))
+	for _, source := range options.PolicySources {
+		sources[source] = true
+	}
+	return sources
 }
 
-// GetPolicyOpsTotal returns the policy operations total counter metric.
-func (p *PrometheusLogger) GetPolicyOpsTotal() *prometheus.CounterVec {
-	return p.policyOpsTotal
+// policySourceLabelValue returns the source label value of source.
+func (p *PrometheusLogger) policySourceLabelValue(source string) string {
+	switch {
+	case source == "":
+		return "unknown"
+	case p.policySources[source]:
+		return source
+	default:
+		return "other"
+	}
+}
+
+// newEnforceMetrics creates the enforce metrics with the given labels.
+func newEnforceMetrics(factory promauto.Factory, options *PrometheusLoggerOptions, labels []string) (*prometheus.HistogramVec, *prometheus.CounterVec) {
+	buckets := options.durationBuckets(prometheus.DefBuckets)
+	if options.EnforceDurationBuckets != nil {
+		buckets = options.durationBuckets(options.EnforceDurationBuckets)
+	}
+	if options.CountOnlyDuration {
+		// An empty slice would select the default buckets, while an
+		// explicit +Inf bound is dropped as implicit, leaving only +Inf.
+		buckets = []float64{math.Inf(1)}
+	}
+	enforceDuration := factory.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    options.metricName("casbin_enforce_duration_seconds", histogramMetric),
+			Help:    options.metricHelp("casbin_enforce_duration_seconds", "Duration of enforce requests in seconds"),
+			Buckets: buckets,
+		},
+		labels,
+	)
+	enforceTotal := factory.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: options.metricName("casbin_enforce_total", counterMetric),
+			Help: options.metricHelp("casbin_enforce_total", "Total number of enforce requests"),
+		},
+		labels,
+	)
+	return enforceDuration, enforceTotal
+}
+
+// SetEventTypes configures which event types should be logged. With
+// PruneOnDisable, the series of the event types it disables are deleted.
+func (p *PrometheusLogger) SetEventTypes(eventTypes []EventType) error {
+	previous := p.enabledEventTypes
+	p.enabledEventTypes = make(map[EventType]bool)
+	for _, eventType := range eventTypes {
+		p.enabledEventTypes[eventType] = true
+	}
+
+	if p.pruneOnDisable && len(p.enabledEventTypes) > 0 {
+		// An empty set enables every event type.
+		if len(previous) == 0 {
+			previous = make(map[EventType]bool)
+			for _, eventType := range knownEventTypes() {
+				previous[eventType] = true
+			}
+		}
+		for eventType := range previous {
+			if !p.enabledEventTypes[eventType] {
+				p.pruneEventType(eventType)
+			}
+		}
+	}
+	return nil
+}
+
+// deleteEnforceSeries deletes the enforce series matching labels.
+func (p *PrometheusLogger) deleteEnforceSeries(labels prometheus.Labels) {
+	if p.enforceTotal != nil {
+		p.enforceTotal.DeletePartialMatch(labels)
+	}
+	if p.enforceDuration != nil {
+		p.enforceDuration.DeletePartialMatch(labels)
+	}
+	if p.enforceDurationTotal != nil {
+		p.enforceDurationTotal.DeletePartialMatch(labels)
+	}
+}
+
+// pruneEventType deletes the series recorded for events of eventType.
+func (p *PrometheusLogger) pruneEventType(eventType EventType) {
+	if eventType == EventEnforce || eventType == EventEnforceEx {
+		// Without the event type label, both share the enforce series.
+		if slices.Contains(p.enforceLabels, LabelEventType) {
+			p.deleteEnforceSeries(prometheus.Labels{LabelEventType: string(eventType)})
+		} else if eventType == EventEnforce {
+			p.ResetEnforceMetrics()
+		}
+		return
+	}
+
+	operation := prometheus.Labels{"operation": string(eventType)}
+	if p.policyOpsTotal != nil {
+		p.policyOpsTotal.DeletePartialMatch(operation)
+	}
+	if p.policyOpsDuration != nil {
+		p.policyOpsDuration.DeletePartialMatch(operation)
+	}
+	if p.policyRulesCount != nil {
+		p.policyRulesCount.DeletePartialMatch(operation)
+	}
+	if p.policyUpdateNet != nil {
+		p.policyUpdateNet.DeletePartialMatch(operation)
+	}
+}
+
+// SetCallbackEventTypes restricts the log callback to events of the given
+// types, independently of which events are recorded in metrics. An empty list
+// invokes the callback for every active event again.
+func (p *PrometheusLogger) SetCallbackEventTypes(types []EventType) {
+	callbackEventTypes := make(map[EventType]bool, len(types))
+	for _, eventType := range types {
+		callbackEventTypes[eventType] = true
+	}
+	p.callbackEventTypes = callbackEventTypes
+}
+
+// isEventTypeEnabled reports whether events of eventType should be logged.
+func (p *PrometheusLogger) isEventTypeEnabled(eventType EventType) bool {
+	return len(p.enabledEventTypes) == 0 || p.enabledEventTypes[eventType]
+}
+
+// OnBeforeEvent is called before an event occurs.
+func (p *PrometheusLogger) OnBeforeEvent(entry *LogEntry) error {
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	if !p.isEventTypeEnabled(entry.EventType) {
+		entry.IsActive = false
+		if p.eventsFiltered != nil {
+			p.eventsFiltered.WithLabelValues(string(entry.EventType)).Inc()
+		}
+		return nil
+	}
+
+	entry.IsActive = true
+	entry.StartTime = p.now()
+	if p.concurrency != nil && (entry.EventType == EventEnforce || entry.EventType == EventEnforceEx) {
+		entry.concurrencyDomain = p.domainLabelValue(entry)
+		p.addConcurrency(entry.concurrencyDomain, 1)
+	}
+	return nil
+}
+
+// OnAfterEvent is called after an event completes and records metrics.
+func (p *PrometheusLogger) OnAfterEvent(entry *LogEntry) error {
+	return p.OnAfterEventCtx(context.Background(), entry)
+}
+
+// OnAfterEventCtx is like OnAfterEvent, and additionally takes the values of
+// the ContextLabels of the enforce metrics from ctx.
+func (p *PrometheusLogger) OnAfterEventCtx(ctx context.Context, entry *LogEntry) error {
+	if !entry.IsActive {
+		return nil
+	}
+
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	p.completeEntry(entry)
+	if err := p.validateEntry(entry); err != nil {
+		return err
+	}
+
+	// Record metrics based on event type
+	switch entry.EventType {
+	case EventEnforce, EventEnforceEx:
+		p.recordEnforceMetrics(ctx, p.consistentEnforceEntry(entry))
+	case EventAddPolicy, EventRemovePolicy, EventLoadPolicy, EventSavePolicy,
+		EventAddPolicies, EventRemovePolicies, EventRemoveFilteredPolicy, EventUpdatePolicy, EventUpdatePolicies:
+		p.recordPolicyMetrics(entry)
+	default:
+		if isCustomEventType(entry.EventType) {
+			p.recordPolicyMetrics(entry)
+		} else if p.unknownEvents != nil {
+			p.unknownEvents.WithLabelValues(string(entry.EventType)).Inc()
+		}
+	}
+	p.observe(entry)
+
+	// Call custom callback if set
+	if p.callback != nil && (len(p.callbackEventTypes) == 0 || p.callbackEventTypes[entry.EventType]) &&
+		(p.callbackFilter == nil || p.callbackFilter(entry)) {
+		return p.runCallback(entry)
+	}
+
+	return nil
+}
+
+// completeEntry sets the end time and duration of an active entry and ends
+// its in-flight count. The caller must hold reconfigureMu for reading.
+func (p *PrometheusLogger) completeEntry(entry *LogEntry) {
+	entry.EndTime = p.now()
+	entry.Duration = entry.EndTime.Sub(entry.StartTime)
+
+	// The concurrency may have been disabled by Reconfigure since
+	// OnBeforeEvent counted the entry.
+	if entry.concurrencyDomain != "" && p.concurrency != nil {
+		p.addConcurrency(entry.concurrencyDomain, -1)
+		entry.concurrencyDomain = ""
+	}
+}
+
+// validateEntry validates entry if StrictEntries is set, counting it in
+// casbin_invalid_entries_total if it is invalid.
+func (p *PrometheusLogger) validateEntry(entry *LogEntry) error {
+	if !p.strictEntries {
+		return nil
+	}
+	if err := entry.Validate(p.enforceLabels); err != nil {
+		if p.invalidEntries != nil {
+			p.invalidEntries.Inc()
+		}
+		return err
+	}
+	return nil
+}
+
+// consistentEnforceEntry returns the enforce entry to record. With StrictMode,
+// an entry that is allowed despite an error is counted as an inconsistency and
+// recorded as a denied, failed request; the entry itself is not modified.
+func (p *PrometheusLogger) consistentEnforceEntry(entry *LogEntry) *LogEntry {
+	if !p.strictMode || !entry.Allowed || entry.Error == nil {
+		return entry
+	}
+
+	if p.entryInconsistencies != nil {
+		p.entryInconsistencies.Inc()
+	}
+	corrected := *entry
+	corrected.Allowed = false
+	return &corrected
+}
+
+// runCallback invokes the log callback, in a goroutine bounded by the
+// callback timeout if CallbackTimeout is set.
+func (p *PrometheusLogger) runCallback(entry *LogEntry) error {
+	if p.callbackTimeout <= 0 {
+		return p.callCallback(entry)
+	}
+
+	// The copy keeps the callback from racing with the caller reusing the
+	// entry after a timeout.
+	entryCopy := *entry
+	done := make(chan error, 1)
+	go func() {
+		done <- p.callCallback(&entryCopy)
+	}()
+
+	timer := time.NewTimer(p.callbackTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		if p.callbackTimeouts != nil {
+			p.callbackTimeouts.Inc()
+		}
+		return fmt.Errorf("%w after %v", ErrCallbackTimeout, p.callbackTimeout)
+	}
+}
+
+// callCallback calls the log callback, recovering a panic if
+// RecoverCallbackPanics is set.
+func (p *PrometheusLogger) callCallback(entry *LogEntry) (err error) {
+	if p.recoverPanics {
+		defer func() {
+			if r := recover(); r != nil {
+				if p.callbackPanics != nil {
+					p.callbackPanics.Inc()
+				}
+				err = fmt.Errorf("%w: %v", ErrCallbackPanic, r)
+			}
+		}()
+	}
+	if p.callbackDuration != nil {
+		start := time.Now()
+		defer func() {
+			p.callbackDuration.Observe(p.durationValue(time.Since(start)))
+		}()
+	}
+	return p.callback(entry)
+}
+
+// RecordEnforceResults completes an active enforce entry that produced a
+// decision for several objects at once. The subject, action and domain of base
+// are shared, and one enforce sample is recorded per result using the single
+// measured duration. Each sample is validated, corrected and observed like an
+// entry passed to OnAfterEvent; if StrictEntries is set and any of them is
+// invalid, none is recorded and the validation error is returned. The log
+// callback is not invoked.
+func (p *PrometheusLogger) RecordEnforceResults(base *LogEntry, results []EnforceResult) error {
+	if !base.IsActive {
+		return nil
+	}
+
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	p.completeEntry(base)
+	entries := make([]LogEntry, len(results))
+	for i, result := range results {
+		entries[i] = *base
+		entries[i].Object = result.Object
+		entries[i].Allowed = result.Allowed
+		if err := p.validateEntry(&entries[i]); err != nil {
+			return err
+		}
+	}
+	for i := range entries {
+		p.recordEnforceMetrics(context.Background(), p.consistentEnforceEntry(&entries[i]))
+		p.observe(&entries[i])
+	}
+	return nil
+}
+
+// SingleShotEnforce records an enforce request that could not be timed, for
+// integrations that have no hook after the decision. Only casbin_enforce_total
+// is incremented; no duration is observed and the log callback is not
+// invoked. Event type filtering and the configured labels apply as usual.
+func (p *PrometheusLogger) SingleShotEnforce(subject, object, action, domain string, allowed bool) {
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	if !p.isEventTypeEnabled(EventEnforce) {
+		return
+	}
+
+	entry := &LogEntry{
+		EventType: EventEnforce,
+		Subject:   subject,
+		Object:    object,
+		Action:    action,
+		Domain:    domain,
+		Allowed:   allowed,
+	}
+	p.lastEnforce.Store(p.now().UnixNano())
+	p.metricSink().IncEnforce(p.enforceSeriesValues(context.Background(), entry))
+}
+
+// AddEnforceCounts records enforce requests tallied elsewhere, such as by a
+// batching layer, adding allowed and denied to casbin_enforce_total for
+// domain. Other enforce labels are recorded empty, no duration is observed
+// and the log callback is not invoked. Nothing is recorded if enforce events
+// are disabled.
+func (p *PrometheusLogger) AddEnforceCounts(domain string, allowed, denied int) {
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	if !p.isEventTypeEnabled(EventEnforce) {
+		return
+	}
+
+	for _, count := range []struct {
+		allowed bool
+		n       int
+	}{{true, allowed}, {false, denied}} {
+		if count.n <= 0 {
+			continue
+		}
+		entry := &LogEntry{EventType: EventEnforce, Domain: domain, Allowed: count.allowed}
+		p.metricSink().AddEnforce(p.enforceSeriesValues(context.Background(), entry), float64(count.n))
+	}
+	p.lastEnforce.Store(p.now().UnixNano())
+}
+
+// Healthy reports whether an enforce request was recorded within maxStaleness,
+// for readiness probes that check the enforcer pipeline is alive. It is always
+// true if maxStaleness is not positive, and false if no enforce request was
+// recorded yet.
+func (p *PrometheusLogger) Healthy(maxStaleness time.Duration) bool {
+	if maxStaleness <= 0 {
+		return true
+	}
+	last := p.lastEnforce.Load()
+	if last == 0 {
+		return false
+	}
+	return p.now().Sub(time.Unix(0, last)) <= maxStaleness
+}
+
+// SetLogCallback sets a custom callback function for log entries. It
+// removes a filter set with SetLogCallbackWithFilter.
+func (p *PrometheusLogger) SetLogCallback(callback func(entry *LogEntry) error) error {
+	p.callback = callback
+	p.callbackFilter = nil
+	return nil
+}
+
+// SetLogCallbackWithFilter sets a custom callback function that is only
+// invoked for the log entries predicate returns true for, such as denials in
+// production domains. Metrics are recorded for every entry regardless.
+func (p *PrometheusLogger) SetLogCallbackWithFilter(predicate func(entry *LogEntry) bool, callback func(entry *LogEntry) error) error {
+	p.callback = callback
+	p.callbackFilter = predicate
+	return nil
+}
+
+// recordEnforceMetrics records metrics for enforce events.
+func (p *PrometheusLogger) recordEnforceMetrics(ctx context.Context, entry *LogEntry) {
+	p.lastEnforce.Store(p.now().UnixNano())
+
+	if p.defaultLabelsFastPath && p.sink == nil {
+		// Calling the Prometheus sink directly rather than through the
+		// interface keeps the label values on the stack.
+		values := [...]string{strconv.FormatBool(entry.Allowed), p.domainLabelValue(entry)}
+		sink := (*prometheusSink)(p)
+		sink.ObserveEnforceDuration(values[:], p.durationValue(entry.Duration))
+		sink.IncEnforce(values[:])
+	} else {
+		values := p.enforceSeriesValues(ctx, entry)
+		sink := p.metricSink()
+		sink.ObserveEnforceDuration(values, p.durationValue(entry.Duration))
+		sink.IncEnforce(values)
+	}
+
+	if p.enforceByHour != nil {
+		hour := strconv.Itoa(entry.EndTime.In(p.hourLocation).Hour())
+		p.enforceByHour.WithLabelValues(hour, strconv.FormatBool(entry.Allowed)).Inc()
+	}
+
+	if p.enforceWeighted != nil && entry.Weight >= 0 {
+		weight := entry.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		p.enforceWeighted.WithLabelValues(p.domainLabelValue(entry)).Add(weight)
+	}
+
+	if p.enforceRulesEvaluated != nil && entry.RulesEvaluated > 0 {
+		p.enforceRulesEvaluated.WithLabelValues(p.domainLabelValue(entry)).Observe(float64(entry.RulesEvaluated))
+	}
+
+	if !entry.EvalStart.IsZero() {
+		if p.enforceWaitDuration != nil {
+			waitStart := entry.WaitStart
+			if waitStart.IsZero() {
+				waitStart = entry.StartTime
+			}
+			p.enforceWaitDuration.WithLabelValues(p.domainLabelValue(entry)).Observe(p.durationValue(entry.EvalStart.Sub(waitStart)))
+		}
+		if p.enforceEvalDuration != nil {
+			p.enforceEvalDuration.WithLabelValues(p.domainLabelValue(entry)).Observe(p.durationValue(entry.EndTime.Sub(entry.EvalStart)))
+		}
+	}
+
+	if p.enforceCPUDuration != nil && entry.CPUDuration > 0 {
+		p.enforceCPUDuration.WithLabelValues(p.domainLabelValue(entry)).Observe(p.durationValue(entry.CPUDuration))
+	}
+
+	if entry.Retries > 0 {
+		if p.enforceRetries != nil {
+			p.enforceRetries.WithLabelValues(p.domainLabelValue(entry)).Add(float64(entry.Retries))
+		}
+		if p.enforceMaxRetries != nil {
+			p.observeMaxRetries(entry.Retries)
+		}
+	}
+
+	if p.enforceLatencyEWMA != nil {
+		p.observeLatencyEWMA(p.domainLabelValue(entry), p.durationValue(entry.Duration))
+	}
+
+	if p.expectedArgCount > 0 && entry.ArgCount > 0 && entry.ArgCount != p.expectedArgCount {
+		if p.enforceArgMismatch != nil {
+			p.enforceArgMismatch.Inc()
+		}
+		if p.onArgMismatch != nil {
+			p.onArgMismatch(entry)
+		}
+	}
+
+	if p.enforceErrorRate != nil {
+		p.enforceErrorRate.observe(entry.Error != nil)
+	}
+
+	if p.distinctSubjects != nil && entry.Subject != "" {
+		p.distinctSubjects.observe(p.domainLabelValue(entry), entry.Subject)
+	}
+
+	if p.enforceAllowRatio != nil {
+		p.enforceAllowRatio.observe(p.domainLabelValue(entry), entry.Allowed)
+	}
+
+	if entry.CacheHit != nil {
+		if *entry.CacheHit {
+			if p.enforceCacheHits != nil {
+				p.enforceCacheHits.WithLabelValues(p.domainLabelValue(entry)).Inc()
+			}
+		} else if p.enforceCacheMisses != nil {
+			p.enforceCacheMisses.WithLabelValues(p.domainLabelValue(entry)).Inc()
+		}
+	}
+
+	if p.enforceDenies != nil && !entry.Allowed {
+		p.enforceDenies.WithLabelValues(p.domainLabelValue(entry), denyCauseLabelValue(entry.DenyCause)).Inc()
+	}
+
+	if p.decisions != nil {
+		p.decisions.add(entry)
+	}
+
+	if p.consecutiveDenies != nil {
+		// Inc and Set are atomic, so concurrent requests of a domain
+		// cannot lose an increment or a reset.
+		streak := p.consecutiveDenies.WithLabelValues(p.domainLabelValue(entry))
+		if entry.Allowed {
+			streak.Set(0)
+		} else {
+			streak.Inc()
+		}
+	}
+
+	if p.enforceSLOViolations != nil {
+		if threshold, ok := p.sloThresholds[entry.Action]; ok && entry.Duration > threshold {
+			p.enforceSLOViolations.WithLabelValues(entry.Action).Inc()
+		}
+	}
+}
+
+// enforceSeriesValues returns the label values entry is recorded with in the
+// enforce metrics, mapping it to the overflow series if MaxSeries is reached.
+func (p *PrometheusLogger) enforceSeriesValues(ctx context.Context, entry *LogEntry) []string {
+	values := p.enforceLabelValues(ctx, entry)
+	if p.series != nil && !p.series.touch(values, p.now()) {
+		values = overflowValues(p.enforceLabels, values)
+		if p.enforceSeriesOverflow != nil {
+			p.enforceSeriesOverflow.Inc()
+		}
+	}
+	return values
+}
+
+// enforceLabelValues returns the label values of entry in the order of the
+// configured enforce labels.
+func (p *PrometheusLogger) enforceLabelValues(ctx context.Context, entry *LogEntry) []string {
+	values := make([]string, len(p.enforceLabels))
+	for i, label := range p.enforceLabels {
+		switch label {
+		case LabelAllowed:
+			values[i] = strconv.FormatBool(entry.Allowed)
+		case LabelDomain:
+			values[i] = p.domainLabelValue(entry)
+		case LabelSubject:
+			values[i] = p.normalizeLabelValue(entry.Subject)
+		case LabelObject:
+			values[i] = p.objectLabelValue(entry.Object)
+		case LabelAction:
+			values[i] = p.actionLabelValue(entry.Action)
+		case LabelOutcome:
+			values[i] = enforceOutcome(entry)
+		case LabelDenyType:
+			values[i] = enforceDenyType(entry)
+		case LabelEventType:
+			values[i] = string(entry.EventType)
+		default:
+			if p.attributeLabels[label] {
+				values[i] = p.attributeLabelValue(entry, label)
+			} else {
+				values[i] = p.sanitizeLabelValue(p.contextLabels[label].value(ctx))
+			}
+		}
+	}
+	return values
+}
+
+// observeMaxRetries raises enforceMaxRetries to retries if it is larger.
+func (p *PrometheusLogger) observeMaxRetries(retries int) {
+	p.maxRetriesMu.Lock()
+	defer p.maxRetriesMu.Unlock()
+
+	if retries > p.maxRetries {
+		p.maxRetries = retries
+		p.enforceMaxRetries.Set(float64(retries))
+	}
+}
+
+// addConcurrency adds delta to the in-flight enforce requests of domain.
+func (p *PrometheusLogger) addConcurrency(domain string, delta int) {
+	p.concurrencyMu.Lock()
+	defer p.concurrencyMu.Unlock()
+
+	n := p.concurrency[domain] + delta
+	p.concurrency[domain] = n
+	if p.domainConcurrency != nil {
+		p.domainConcurrency.WithLabelValues(domain).Set(float64(n))
+	}
+	if n > p.concurrencyMax[domain] {
+		p.concurrencyMax[domain] = n
+		if p.domainConcurrencyMax != nil {
+			p.domainConcurrencyMax.WithLabelValues(domain).Set(float64(n))
+		}
+	}
+}
+
+// observeLatencyEWMA moves the latency average of domain towards latency. The
+// first request of a domain sets the average.
+func (p *PrometheusLogger) observeLatencyEWMA(domain string, latency float64) {
+	p.latencyEWMAMu.Lock()
+	defer p.latencyEWMAMu.Unlock()
+
+	average, ok := p.latencyEWMA[domain]
+	if ok {
+		average += p.latencyEWMAAlpha * (latency - average)
+	} else {
+		average = latency
+	}
+	p.latencyEWMA[domain] = average
+	p.enforceLatencyEWMA.WithLabelValues(domain).Set(average)
+}
+
+// enforceOutcome returns the outcome label value of entry.
+func enforceOutcome(entry *LogEntry) string {
+	switch {
+	case entry.Error != nil:
+		return OutcomeError
+	case entry.Allowed:
+		return OutcomeAllow
+	default:
+		return OutcomeDeny
+	}
+}
+
+// denyCauseLabelValue returns the deny cause label value of cause, mapping
+// values outside the fixed set to DenyCauseUnknown to bound the cardinality.
+func denyCauseLabelValue(cause string) string {
+	switch cause {
+	case DenyCauseNoRole, DenyCauseRoleDenied, DenyCauseNoPolicy:
+		return cause
+	default:
+		return DenyCauseUnknown
+	}
+}
+
+// DenyCauseOf returns the deny cause of a request denied by EnforceEx with
+// the explanation explain, the rule that decided it, if any. hasRole reports
+// whether the subject has any role, for example
+// len(e.GetImplicitRolesForUser(sub)) > 0. A matching rule of a denied
+// request is a deny rule.
+func DenyCauseOf(explain []string, hasRole bool) string {
+	switch {
+	case len(explain) > 0:
+		return DenyCauseRoleDenied
+	case !hasRole:
+		return DenyCauseNoRole
+	default:
+		return DenyCauseNoPolicy
+	}
+}
+
+// enforceDenyType returns the deny type label value of entry.
+func enforceDenyType(entry *LogEntry) string {
+	switch {
+	case entry.Allowed:
+		return DenyTypeNone
+	case entry.ExplicitDeny:
+		return DenyTypeExplicit
+	default:
+		return DenyTypeDefault
+	}
+}
+
+// attributeLabelValue returns the value of the attribute label of entry, or
+// "none" if the attribute is missing or empty.
+func (p *PrometheusLogger) attributeLabelValue(entry *LogEntry, label string) string {
+	if value := p.normalizeLabelValue(entry.Attributes[label]); value != "" {
+		return value
+	}
+	return noneAttributeLabelValue
+}
+
+// domainLabelValue returns the domain label value of entry, "default" if empty.
+func (p *PrometheusLogger) domainLabelValue(entry *LogEntry) string {
+	domain := p.normalizeLabelValue(entry.Domain)
+	if domain == "" {
+		return "default"
+	}
+	return domain
+}
+
+// objectLabelValue returns the object label value of object, grouped by
+// ObjectGrouper if it is set.
+func (p *PrometheusLogger) objectLabelValue(object string) string {
+	if p.objectGrouper != nil {
+		return p.sanitizeLabelValue(p.objectGrouper(object))
+	}
+	return p.normalizeLabelValue(object)
+}
+
+// metricSink returns the MetricSink option, or the Prometheus sink if it is
+// not set.
+func (p *PrometheusLogger) metricSink() MetricSink {
+	if p.sink != nil {
+		return p.sink
+	}
+	return (*prometheusSink)(p)
+}
+
+// actionLabelValue returns the action label value of action, classified by
+// ActionClassifier if it is set.
+func (p *PrometheusLogger) actionLabelValue(action string) string {
+	if p.actionClassifier != nil {
+		return p.sanitizeLabelValue(p.actionClassifier(action))
+	}
+	return p.normalizeLabelValue(action)
+}
+
+// normalizeLabelValue trims and lowercases value if NormalizeLabelValues is
+// set, and sanitizes it.
+func (p *PrometheusLogger) normalizeLabelValue(value string) string {
+	if !p.normalize {
+		return p.sanitizeLabelValue(value)
+	}
+	return p.sanitizeLabelValue(strings.ToLower(strings.TrimSpace(value)))
+}
+
+// sanitizeLabelValue replaces the invalid UTF-8 sequences of value with the
+// Unicode replacement character if SanitizeUTF8 is set.
+func (p *PrometheusLogger) sanitizeLabelValue(value string) string {
+	if !p.sanitizeUTF8 || utf8.ValidString(value) {
+		return value
+	}
+	return strings.ToValidUTF8(value, string(utf8.RuneError))
+}
+
+// PolicyOpSuccessRate returns the fraction of successful policy operations of
+// type op recorded so far, or NaN if none were recorded.
+func (p *PrometheusLogger) PolicyOpSuccessRate(op EventType) float64 {
+	if p.policyOpsTotal == nil {
+		return math.NaN()
+	}
+	families, err := gatherCollectors(p.policyOpsTotal)
+	if err != nil {
+		return math.NaN()
+	}
+
+	var success, total float64
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := labelMap(metric.GetLabel())
+			if labels["operation"] != string(op) {
+				continue
+			}
+			value := metric.GetCounter().GetValue()
+			total += value
+			if labels[p.policySuccessName] == p.policySuccessValue {
+				success += value
+			}
+		}
+	}
+
+	if total == 0 {
+		return math.NaN()
+	}
+	return success / total
+}
+
+// recordPolicyMetrics records metrics for policy operation events.
+func (p *PrometheusLogger) recordPolicyMetrics(entry *LogEntry) {
+	operation := string(entry.EventType)
+	success := p.policySuccessValue
+	if entry.Error != nil {
+		success = p.policyFailureValue
+	}
+
+	values := []string{operation, success}
+	if p.policyPtype {
+		values = append(values, entry.Ptype)
+	}
+	if p.policyAdapter {
+		values = append(values, entry.Adapter)
+	}
+	durationValues := []string{operation}
+	if p.policyAdapter {
+		durationValues = append(durationValues, entry.Adapter)
+	}
+	if p.policySources != nil {
+		source := p.policySourceLabelValue(entry.Source)
+		values = append(values, source)
+		durationValues = append(durationValues, source)
+	}
+	if p.sink != nil {
+		// The clones keep the label values of the Prometheus sink, which
+		// is called directly, on the stack.
+		p.sink.IncPolicyOp(slices.Clone(values))
+		p.sink.ObservePolicyOpDuration(slices.Clone(durationValues), p.durationValue(entry.Duration))
+	} else {
+		sink := (*prometheusSink)(p)
+		sink.IncPolicyOp(values)
+		sink.ObservePolicyOpDuration(durationValues, p.durationValue(entry.Duration))
+	}
+
+	if p.policyOpErrors != nil && entry.Error != nil {
+		category := entry.ErrorCategory
+		if category == "" {
+			category = "unknown"
+		}
+		p.policyOpErrors.WithLabelValues(operation, category).Inc()
+	}
+
+	if p.policyRulesCount != nil && entry.RuleCount > 0 {
+		p.policyRulesCount.WithLabelValues(operation).Set(float64(entry.RuleCount))
+	}
+
+	if p.policyUpdateNet != nil && entry.Error == nil && (entry.EventType == EventUpdatePolicy || entry.EventType == EventUpdatePolicies) {
+		p.policyUpdateNet.WithLabelValues(operation).Observe(float64(entry.NewRuleCount - entry.OldRuleCount))
+	}
+
+	if p.policyReloadInterval != nil && entry.EventType == EventLoadPolicy {
+		p.observeReloadInterval(entry.EndTime)
+	}
+}
+
+// durationValue returns d in the unit of the duration metrics.
+func (p *PrometheusLogger) durationValue(d time.Duration) float64 {
+	return d.Seconds() * p.durationScale
+}
+
+// observeReloadInterval records the time since the previous policy load, if
+// any, and remembers loadTime as the last load.
+func (p *PrometheusLogger) observeReloadInterval(loadTime time.Time) {
+	p.lastLoadMu.Lock()
+	defer p.lastLoadMu.Unlock()
+
+	if !p.lastLoad.IsZero() {
+		p.policyReloadInterval.Observe(p.durationValue(loadTime.Sub(p.lastLoad)))
+	}
+	p.lastLoad = loadTime
+}
+
+// UpdatePolicyState sets the current number of policy rules for ptype,
+// such as "p" or "g".
+func (p *PrometheusLogger) UpdatePolicyState(ptype string, count int) {
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	if p.policyStateCount == nil {
+		return
+	}
+	p.policyStateMu.Lock()
+	defer p.policyStateMu.Unlock()
+
+	p.policyStateCount.WithLabelValues(ptype).Set(float64(count))
+	p.policyStatePtypes[ptype] = true
+}
+
+// UpdatePolicyStateBulk applies a snapshot of policy rule counts by ptype.
+// If pruneMissing is true, the series of ptypes set earlier but missing from
+// counts are deleted. Concurrent updates are serialized, but a scrape may
+// observe a snapshot that is only partially applied.
+func (p *PrometheusLogger) UpdatePolicyStateBulk(counts map[string]int, pruneMissing bool) {
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	if p.policyStateCount == nil {
+		return
+	}
+	p.policyStateMu.Lock()
+	defer p.policyStateMu.Unlock()
+
+	for ptype, count := range counts {
+		p.policyStateCount.WithLabelValues(ptype).Set(float64(count))
+		p.policyStatePtypes[ptype] = true
+	}
+
+	if !pruneMissing {
+		return
+	}
+	for ptype := range p.policyStatePtypes {
+		if _, ok := counts[ptype]; !ok {
+			p.policyStateCount.DeleteLabelValues(ptype)
+			delete(p.policyStatePtypes, ptype)
+		}
+	}
+}
+
+// UpdateRoleClosureSize sets the number of effective role edges for the role
+// ptype, such as "g", after transitive expansion. It can be computed with the
+// enforcer by summing, over all subjects and roles, the number of roles
+// returned by GetImplicitRolesForUser:
+//
+//	size := 0
+//	for _, name := range append(e.GetAllSubjects(), e.GetAllRoles()...) {
+//		roles, _ := e.GetImplicitRolesForUser(name)
+//		size += len(roles)
+//	}
+//	logger.UpdateRoleClosureSize("g", size)
+func (p *PrometheusLogger) UpdateRoleClosureSize(ptype string, size int) {
+	if p.roleClosureSize != nil {
+		p.roleClosureSize.WithLabelValues(ptype).Set(float64(size))
+	}
+}
+
+// internalErrorCategories maps message prefixes of internal errors to the
+// category label of casbin_internal_errors_total. Messages without a known
+// prefix are counted as "other", which keeps the label cardinality bounded.
+var internalErrorCategories = []struct {
+	prefix   string
+	category string
+}{
+	{"adapter", "adapter"},
+	{"watcher", "watcher"},
+	{"dispatcher", "dispatcher"},
+	{"role manager", "role_manager"},
+	{"model", "model"},
+	{"policy", "policy"},
+}
+
+// LogError counts an internal casbin error that is not tied to a single event,
+// matching the LogError method of casbin's logger. The error is categorized by
+// the prefix of msg, or of the error text if msg is empty.
+func (p *PrometheusLogger) LogError(err error, msg ...string) {
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	if p.internalErrors != nil {
+		p.internalErrors.WithLabelValues(internalErrorCategory(err, msg)).Inc()
+	}
+}
+
+// RecordModelLoad records a load of the model, as opposed to the policy,
+// which can be costly with complex matchers. casbin's LogModel carries no
+// timing, so time the load where it happens:
+//
+//	start := time.Now()
+//	m, err := model.NewModelFromFile("model.conf")
+//	logger.RecordModelLoad(time.Since(start), err)
+func (p *PrometheusLogger) RecordModelLoad(duration time.Duration, err error) {
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	success := strconv.FormatBool(err == nil)
+	if p.modelLoadDuration != nil {
+		p.modelLoadDuration.WithLabelValues(success).Observe(p.durationValue(duration))
+	}
+	if p.modelLoadTotal != nil {
+		p.modelLoadTotal.WithLabelValues(success).Inc()
+	}
+}
+
+// LogPolicy updates the policy state from a full policy snapshot by ptype,
+// matching the LogPolicy method of casbin's logger. The rule count of each
+// ptype is set and the series of ptypes missing from policy are deleted.
+func (p *PrometheusLogger) LogPolicy(policy map[string][][]string) {
+	counts := make(map[string]int, len(policy))
+	for ptype, rules := range policy {
+		counts[ptype] = len(rules)
+	}
+	p.UpdatePolicyStateBulk(counts, true)
+}
+
+// internalErrorCategory returns the category label value of an internal error.
+func internalErrorCategory(err error, msg []string) string {
+	text := strings.Join(msg, " ")
+	if strings.TrimSpace(text) == "" && err != nil {
+		text = err.Error()
+	}
+	text = strings.ToLower(strings.TrimSpace(text))
+
+	for _, c := range internalErrorCategories {
+		if strings.HasPrefix(text, c.prefix) {
+			return c.category
+		}
+	}
+	return "other"
+}
+
+// ResetEnforceMetrics deletes every series of the enforce total and duration
+// metrics, for example to drop stale subjects after a configuration change.
+// Policy operation metrics are left untouched so that they stay monotonic.
+func (p *PrometheusLogger) ResetEnforceMetrics() {
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	p.resetEnforceMetrics()
+}
+
+// resetEnforceMetrics is ResetEnforceMetrics without locking. The caller must
+// hold reconfigureMu for reading.
+func (p *PrometheusLogger) resetEnforceMetrics() {
+	if p.enforceTotal != nil {
+		p.enforceTotal.Reset()
+	}
+	if p.enforceDuration != nil {
+		p.enforceDuration.Reset()
+	}
+	if p.enforceDurationTotal != nil {
+		p.enforceDurationTotal.Reset()
+	}
+	if p.series != nil {
+		p.series.reset()
+	}
+}
+
+// Reset deletes every series of the enforce metrics, like
+// ResetEnforceMetrics, and of casbin_policy_state_count, for example before
+// repopulating the policy state from a background poller after a reload.
+//
+// Reset is safe to call concurrently with UpdatePolicyState and
+// UpdatePolicyStateBulk. The policy state updates and the reset are applied
+// one at a time, so a ptype is either cleared or set to a value passed to an
+// update, and the state converges to the updates made after the last reset.
+// A scrape is not atomic across ptypes, though, and may observe some ptypes
+// before and others after a concurrent reset.
+func (p *PrometheusLogger) Reset() {
+	p.reconfigureMu.RLock()
+	defer p.reconfigureMu.RUnlock()
+
+	p.resetEnforceMetrics()
+	if p.policyStateCount == nil {
+		return
+	}
+	p.policyStateMu.Lock()
+	defer p.policyStateMu.Unlock()
+
+	p.policyStateCount.Reset()
+	clear(p.policyStatePtypes)
+}
+
+// Close stops the background goroutines started by the logger by cancelling
+// its context, derived from BaseContext. It does not unregister the metrics.
+func (p *PrometheusLogger) Close() {
+	p.cancel()
+}
+
+// Unregister unregisters all metrics from the default Prometheus registry.
+// This is useful for testing or when you need to recreate the logger.
+func (p *PrometheusLogger) Unregister() {
+	for _, collector := range p.collectors() {
+		prometheus.Unregister(collector)
+	}
+}
+
+// AddMirrorRegistry registers the metrics of the logger with an additional
+// registry, so that every record is visible to both, for example a local
+// scrape registry and one pushed to a remote write endpoint. The metrics are
+// shared rather than copied. If any metric cannot be registered, the ones
+// already registered with r are unregistered again and the error is returned.
+// Use UnregisterFrom to remove the mirror.
+func (p *PrometheusLogger) AddMirrorRegistry(r *prometheus.Registry) error {
+	if err := p.register(r); err != nil {
+		return fmt.Errorf("registering mirror metrics: %w", err)
+	}
+	return nil
+}
+
+// Reconfigure replaces the configuration of the logger with options at
+// runtime, for example to apply new enforce labels, buckets or a namespace
+// after a configuration reload. The metrics are rebuilt, so their recorded
+// values are reset, and the old metrics are unregistered from the registry
+// the logger was registered with before the new ones are registered. If the
+// options are invalid or the new metrics cannot be registered, the old
+// metrics are registered again and the error is returned. A registry does
+// not accept a metric name again with other label names, even after it was
+// unregistered, so changing the labels of a metric also requires changing
+// its name, for example with Namespace or DurationUnit. The event types,
+// callback and mirror registries are kept; mirrors still export the old
+// metrics. A logger created by NewPrometheusLoggerWithFactory cannot be
+// reconfigured, because its registry is unknown; Reconfigure returns
+// ErrFactoryRegistry and keeps the current metrics. Reconfigure is safe to
+// call concurrently with the methods that record into or reset the metrics,
+// such as OnBeforeEvent, OnAfterEvent, SingleShotEnforce, AddEnforceCounts,
+// UpdatePolicyState, LogError, Reset and Merge, but not with the methods that
+// change the event types or callback.
+func (p *PrometheusLogger) Reconfigure(options *PrometheusLoggerOptions) error {
+	next, err := newPrometheusLogger(options)
+	if err != nil {
+		return err
+	}
+
+	p.reconfigureMu.Lock()
+	defer p.reconfigureMu.Unlock()
+
+	registerer, err := p.registerer()
+	if err != nil {
+		next.Close()
+		return err
+	}
+	old := p.metricCollectors()
+	for _, collector := range old {
+		registerer.Unregister(collector)
+	}
+	if err := next.register(registerer); err != nil {
+		next.Close()
+		for _, collector := range old {
+			// The collectors were registered before, so this only fails
+			// if another goroutine took their names in the meantime.
+			_ = registerer.Register(collector)
+		}
+		return fmt.Errorf("registering reconfigured metrics: %w", err)
+	}
+
+	p.adopt(next)
+	return nil
+}
+
+// adopt replaces the configuration and metrics of the logger with those of
+// next, keeping the event types, callback, registry and last enforce time.
+// The idle series sweeper of next, if any, replaces that of the logger.
+func (p *PrometheusLogger) adopt(next *PrometheusLogger) {
+	p.enforceLabels = next.enforceLabels
+	p.attributeLabels = next.attributeLabels
+	p.contextLabels = next.contextLabels
+	p.defaultLabelsFastPath = next.defaultLabelsFastPath
+	p.series = next.series
+	p.hourLocation = next.hourLocation
+	p.normalize = next.normalize
+	p.sanitizeUTF8 = next.sanitizeUTF8
+	p.actionClassifier = next.actionClassifier
+	p.objectGrouper = next.objectGrouper
+	p.sink = next.sink
+	p.decisions = next.decisions
+	p.sloThresholds = next.sloThresholds
+	p.callbackTimeout = next.callbackTimeout
+	p.expectedArgCount = next.expectedArgCount
+	p.onArgMismatch = next.onArgMismatch
+	p.policyPtype = next.policyPtype
+	p.policyAdapter = next.policyAdapter
+	p.policySources = next.policySources
+	p.pruneOnDisable = next.pruneOnDisable
+	p.recoverPanics = next.recoverPanics
+	p.strictEntries = next.strictEntries
+	p.strictMode = next.strictMode
+	p.durationScale = next.durationScale
+	p.countOnlyDuration = next.countOnlyDuration
+	p.metricName = next.metricName
+	p.policySuccessName = next.policySuccessName
+	p.policySuccessValue = next.policySuccessValue
+	p.policyFailureValue = next.policyFailureValue
+
+	p.cancel()
+	p.ctx = next.ctx
+	p.cancel = next.cancel
+
+	// Storing the nil collector of a disabled metric clears the field.
+	nextFields := next.metricFields()
+	for i, field := range p.metricFields() {
+		storeCollector(field, loadCollector(nextFields[i]))
+	}
+
+	p.policyStateMu.Lock()
+	p.policyStatePtypes = next.policyStatePtypes
+	p.policyStateMu.Unlock()
+	p.lastLoadMu.Lock()
+	p.lastLoad = time.Time{}
+	p.lastLoadMu.Unlock()
+	p.maxRetriesMu.Lock()
+	p.maxRetries = 0
+	p.maxRetriesMu.Unlock()
+	p.latencyEWMAMu.Lock()
+	p.latencyEWMA = next.latencyEWMA
+	p.latencyEWMAAlpha = next.latencyEWMAAlpha
+	p.latencyEWMAMu.Unlock()
+	p.concurrencyMu.Lock()
+	// Keep counting the requests in flight if the concurrency stays enabled.
+	if p.concurrency == nil || next.concurrency == nil {
+		p.concurrency = next.concurrency
+		p.concurrencyMax = next.concurrencyMax
+	}
+	p.concurrencyMu.Unlock()
+}
+
+// registerer returns the registry the metrics of the logger are registered
+// with, for registering metrics with it later. It returns ErrFactoryRegistry
+// for a logger created by a promauto factory.
+func (p *PrometheusLogger) registerer() (prometheus.Registerer, error) {
+	if p.fromFactory {
+		return nil, ErrFactoryRegistry
+	}
+	registerer, ok := p.gatherer.(prometheus.Registerer)
+	if !ok {
+		return nil, errors.New("the registry of the logger does not support registering metrics")
+	}
+	return registerer, nil
+}
+
+// register registers the metrics of the logger with r. If any metric cannot
+// be registered, the ones already registered are unregistered again.
+func (p *PrometheusLogger) register(r prometheus.Registerer) error {
+	collectors := p.collectors()
+	for i, collector := range collectors {
+		if err := r.Register(collector); err != nil {
+			for _, registered := range collectors[:i] {
+				r.Unregister(registered)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// UnregisterFrom unregisters all metrics from a specific Prometheus registry.
+func (p *PrometheusLogger) UnregisterFrom(registry *prometheus.Registry) bool {
+	result := true
+	for _, collector := range p.collectors() {
+		result = registry.Unregister(collector) && result
+	}
+	return result
+}
+
+// collectors returns every metric collector owned by the logger, including
+// the histograms of the observers.
+func (p *PrometheusLogger) collectors() []prometheus.Collector {
+	collectors := p.metricCollectors()
+	for _, o := range p.observers {
+		collectors = append(collectors, o.histogram)
+	}
+	return collectors
+}
+
+// metricCollectors returns the metric collectors created from the options of
+// the logger.
+func (p *PrometheusLogger) metricCollectors() []prometheus.Collector {
+	var collectors []prometheus.Collector
+	for _, field := range p.metricFields() {
+		if collector := loadCollector(field); collector != nil {
+			collectors = append(collectors, collector)
+		}
+	}
+	return collectors
+}
+
+// MetricNames returns the sorted, fully-qualified names of every metric the
+// logger registers, including the enabled optional ones. This is useful to
+// configure scrape relabeling and allowlists.
+func (p *PrometheusLogger) MetricNames() []string {
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		for _, collector := range p.collectors() {
+			collector.Describe(descs)
+		}
+		close(descs)
+	}()
+
+	seen := make(map[string]bool)
+	var names []string
+	for desc := range descs {
+		// Desc does not expose its name, so take it from the description.
+		match := descNameRegexp.FindStringSubmatch(desc.String())
+		if match == nil || seen[match[1]] {
+			continue
+		}
+		seen[match[1]] = true
+		names = append(names, match[1])
+	}
+	sort.Strings(names)
+	return names
+}
+
+// descNameRegexp matches the fully-qualified name in the description of a prometheus.Desc.
+var descNameRegexp = regexp.MustCompile(`fqName: "([^"]*)"`)
+
+// labelMap converts label pairs into a map from label name to value.
+func labelMap(pairs []*dto.LabelPair) map[string]string {
+	labels := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
+
+// metricFields returns pointers to the metric fields of the logger. Optional
+// metrics that are not enabled hold nil.
+func (p *PrometheusLogger) metricFields() []any {
+	return []any{
+		&p.enforceDuration,
+		&p.enforceTotal,
+		&p.policyOpsTotal,
+		&p.policyOpsDuration,
+		&p.policyRulesCount,
+		&p.policyStateCount,
+		&p.unknownEvents,
+		&p.roleClosureSize,
+		&p.internalErrors,
+		&p.eventsFiltered,
+		&p.policyUpdateNet,
+		&p.modelLoadDuration,
+		&p.modelLoadTotal,
+		&p.enforceSeriesOverflow,
+		&p.loggerSeries,
+		&p.enforceDurationTotal,
+		&p.enforceByHour,
+		&p.enforceWeighted,
+		&p.enforceSLOViolations,
+		&p.callbackPanics,
+		&p.callbackTimeouts,
+		&p.callbackDuration,
+		&p.consecutiveDenies,
+		&p.invalidEntries,
+		&p.entryInconsistencies,
+		&p.enforceRetries,
+		&p.enforceWaitDuration,
+		&p.enforceEvalDuration,
+		&p.enforceCPUDuration,
+		&p.policyReloadInterval,
+		&p.policyOpErrors,
+		&p.enforceMaxRetries,
+		&p.enforceRulesEvaluated,
+		&p.enforceLatencyEWMA,
+		&p.enforceErrorRate,
+		&p.distinctSubjects,
+		&p.enforceAllowRatio,
+		&p.enforceArgMismatch,
+		&p.enforceDenies,
+		&p.enforceCacheHits,
+		&p.enforceCacheMisses,
+		&p.domainConcurrency,
+		&p.domainConcurrencyMax,
+	}
+}
+
+// namedMetricFields maps the names accepted by EnabledMetrics to the metric
+// fields. It must list the same fields as metricFields.
+func (p *PrometheusLogger) namedMetricFields() map[string]any {
+	return map[string]any{
+		"enforce_duration_seconds":           &p.enforceDuration,
+		"enforce_total":                      &p.enforceTotal,
+		"policy_operations_total":            &p.policyOpsTotal,
+		"policy_operations_duration_seconds": &p.policyOpsDuration,
+		"policy_rules_count":                 &p.policyRulesCount,
+		"policy_state_count":                 &p.policyStateCount,
+		"unknown_events_total":               &p.unknownEvents,
+		"role_closure_size":                  &p.roleClosureSize,
+		"internal_errors_total":              &p.internalErrors,
+		"events_filtered_total":              &p.eventsFiltered,
+		"policy_update_net_change":           &p.policyUpdateNet,
+		"model_load_duration_seconds":        &p.modelLoadDuration,
+		"model_load_total":                   &p.modelLoadTotal,
+		"enforce_series_overflow_total":      &p.enforceSeriesOverflow,
+		"logger_series_total":                &p.loggerSeries,
+		"enforce_duration_seconds_total":     &p.enforceDurationTotal,
+		"enforce_by_hour_total":              &p.enforceByHour,
+		"enforce_weighted_total":             &p.enforceWeighted,
+		"enforce_slo_violations_total":       &p.enforceSLOViolations,
+		"callback_panics_total":              &p.callbackPanics,
+		"callback_timeouts_total":            &p.callbackTimeouts,
+		"callback_duration_seconds":          &p.callbackDuration,
+		"enforce_consecutive_denies":         &p.consecutiveDenies,
+		"invalid_entries_total":              &p.invalidEntries,
+		"entry_inconsistencies_total":        &p.entryInconsistencies,
+		"enforce_retries_total":              &p.enforceRetries,
+		"enforce_wait_duration_seconds":      &p.enforceWaitDuration,
+		"enforce_eval_duration_seconds":      &p.enforceEvalDuration,
+		"enforce_cpu_seconds":                &p.enforceCPUDuration,
+		"policy_reload_interval_seconds":     &p.policyReloadInterval,
+		"policy_operation_errors_total":      &p.policyOpErrors,
+		"enforce_max_retries":                &p.enforceMaxRetries,
+		"enforce_rules_evaluated":            &p.enforceRulesEvaluated,
+		"enforce_latency_ewma_seconds":       &p.enforceLatencyEWMA,
+		"enforce_error_rate":                 &p.enforceErrorRate,
+		"enforce_distinct_subjects":          &p.distinctSubjects,
+		"enforce_allow_ratio":                &p.enforceAllowRatio,
+		"enforce_arg_mismatch_total":         &p.enforceArgMismatch,
+		"enforce_denies_total":               &p.enforceDenies,
+		"enforce_cache_hits_total":           &p.enforceCacheHits,
+		"enforce_cache_misses_total":         &p.enforceCacheMisses,
+		"enforce_domain_concurrency":         &p.domainConcurrency,
+		"enforce_domain_concurrency_max":     &p.domainConcurrencyMax,
+	}
+}
+
+// loadCollector returns the collector stored in a metric field, or nil.
+func loadCollector(field any) prometheus.Collector {
+	switch f := field.(type) {
+	case **prometheus.CounterVec:
+		if *f != nil {
+			return *f
+		}
+	case **prometheus.GaugeVec:
+		if *f != nil {
+			return *f
+		}
+	case **prometheus.HistogramVec:
+		if *f != nil {
+			return *f
+		}
+	case *prometheus.Counter:
+		if *f != nil {
+			return *f
+		}
+	case *prometheus.Gauge:
+		if *f != nil {
+			return *f
+		}
+	case *prometheus.Histogram:
+		if *f != nil {
+			return *f
+		}
+	case **errorRateCollector:
+		if *f != nil {
+			return *f
+		}
+	case **distinctSubjectsCollector:
+		if *f != nil {
+			return *f
+		}
+	case **allowRatioCollector:
+		if *f != nil {
+			return *f
+		}
+	}
+	return nil
+}
+
+// storeCollector stores collector in a metric field. It returns false if the
+// collector does not have the type of the field.
+func storeCollector(field any, collector prometheus.Collector) bool {
+	var ok bool
+	switch f := field.(type) {
+	case **prometheus.CounterVec:
+		*f, ok = collector.(*prometheus.CounterVec)
+	case **prometheus.GaugeVec:
+		*f, ok = collector.(*prometheus.GaugeVec)
+	case **prometheus.HistogramVec:
+		*f, ok = collector.(*prometheus.HistogramVec)
+	case *prometheus.Counter:
+		*f, ok = collector.(prometheus.Counter)
+	case *prometheus.Gauge:
+		*f, ok = collector.(prometheus.Gauge)
+	case *prometheus.Histogram:
+		*f, ok = collector.(prometheus.Histogram)
+	case **errorRateCollector:
+		*f, ok = collector.(*errorRateCollector)
+	case **distinctSubjectsCollector:
+		*f, ok = collector.(*distinctSubjectsCollector)
+	case **allowRatioCollector:
+		*f, ok = collector.(*allowRatioCollector)
+	}
+	return ok
+}
+
+// registerDefault registers the metrics with the default Prometheus registry.
+// Metrics that are already registered there, e.g. by an earlier logger, are
+// adopted instead of causing a panic, so both loggers record into the same
+// collectors. Any other registration error panics.
+func (p *PrometheusLogger) registerDefault() {
+	if err := p.registerAdopting(prometheus.DefaultRegisterer); err != nil {
+		panic(err)
+	}
+}
+
+// registerAdopting registers all metrics with r like registerDefault, but
+// returns any other registration error instead of panicking.
+func (p *PrometheusLogger) registerAdopting(r prometheus.Registerer) error {
+	for _, field := range p.metricFields() {
+		collector := loadCollector(field)
+		if collector == nil {
+			continue
+		}
+
+		err := r.Register(collector)
+		if err == nil {
+			continue
+		}
+		are, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok || !storeCollector(field, are.ExistingCollector) {
+			return err
+		}
+	}
+	return nil
+}
+
+// gatherCollectors collects the given collectors into metric families using a
+// throwaway registry, so values can be read without touching the registry the
+// logger was registered with.
+func gatherCollectors(collectors ...prometheus.Collector) ([]*dto.MetricFamily, error) {
+	registry := prometheus.NewRegistry()
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return registry.Gather()
+}
+
+// GetEnforceDuration returns the enforce duration histogram metric.
+func (p *PrometheusLogger) GetEnforceDuration() *prometheus.HistogramVec {
+	return p.enforceDuration
+}
+
+// GetEnforceTotal returns the enforce total counter metric.
+func (p *PrometheusLogger) GetEnforceTotal() *prometheus.CounterVec {
+	return p.enforceTotal
+}
+
+// GetPolicyOpsTotal returns the policy operations total counter metric.
+func (p *PrometheusLogger) GetPolicyOpsTotal() *prometheus.CounterVec {
+	return p.policyOpsTotal
 }
 
 // GetPolicyOpsDuration returns the policy operations duration histogram metric.
@@ -272,3 +2404,233 @@ func (p *PrometheusLogger) GetPolicyOpsDuration() *prometheus.HistogramVec {
 func (p *PrometheusLogger) GetPolicyRulesCount() *prometheus.GaugeVec {
 	return p.policyRulesCount
 }
+
+// GetPolicyUpdateNetChange returns the histogram of the change in the number
+// of policy rules made by update operations.
+func (p *PrometheusLogger) GetPolicyUpdateNetChange() *prometheus.HistogramVec {
+	return p.policyUpdateNet
+}
+
+// GetModelLoadDuration returns the model load duration histogram metric.
+func (p *PrometheusLogger) GetModelLoadDuration() *prometheus.HistogramVec {
+	return p.modelLoadDuration
+}
+
+// GetModelLoadTotal returns the model load counter metric.
+func (p *PrometheusLogger) GetModelLoadTotal() *prometheus.CounterVec {
+	return p.modelLoadTotal
+}
+
+// GetPolicyStateCount returns the policy state count gauge metric.
+func (p *PrometheusLogger) GetPolicyStateCount() *prometheus.GaugeVec {
+	return p.policyStateCount
+}
+
+// GetRoleClosureSize returns the role closure size gauge metric.
+func (p *PrometheusLogger) GetRoleClosureSize() *prometheus.GaugeVec {
+	return p.roleClosureSize
+}
+
+// GetInternalErrors returns the internal errors counter metric.
+func (p *PrometheusLogger) GetInternalErrors() *prometheus.CounterVec {
+	return p.internalErrors
+}
+
+// GetEventsFiltered returns the filtered events counter metric.
+func (p *PrometheusLogger) GetEventsFiltered() *prometheus.CounterVec {
+	return p.eventsFiltered
+}
+
+// GetUnknownEvents returns the unknown events counter metric.
+func (p *PrometheusLogger) GetUnknownEvents() *prometheus.CounterVec {
+	return p.unknownEvents
+}
+
+// GetEnforceSeriesOverflow returns the enforce series overflow counter metric,
+// or nil if MaxSeries is not set.
+func (p *PrometheusLogger) GetEnforceSeriesOverflow() prometheus.Counter {
+	return p.enforceSeriesOverflow
+}
+
+// GetLoggerSeries returns the gauge of the enforce label combinations tracked
+// by the logger, or nil if neither MaxSeries nor IdleSeriesTTL is set.
+func (p *PrometheusLogger) GetLoggerSeries() prometheus.Gauge {
+	return p.loggerSeries
+}
+
+// GetEnforceByHour returns the enforce by hour counter metric, or nil if
+// EnforceByHour is not set.
+func (p *PrometheusLogger) GetEnforceByHour() *prometheus.CounterVec {
+	return p.enforceByHour
+}
+
+// GetEnforceWeighted returns the weighted enforce counter metric, or nil if
+// EnforceWeighted is not set.
+func (p *PrometheusLogger) GetEnforceWeighted() *prometheus.CounterVec {
+	return p.enforceWeighted
+}
+
+// GetEnforceSLOViolations returns the enforce SLO violations counter metric,
+// or nil if SLOThresholds is not set.
+func (p *PrometheusLogger) GetEnforceSLOViolations() *prometheus.CounterVec {
+	return p.enforceSLOViolations
+}
+
+// GetCallbackPanics returns the callback panics counter metric, or nil if
+// RecoverCallbackPanics is not set.
+func (p *PrometheusLogger) GetCallbackPanics() prometheus.Counter {
+	return p.callbackPanics
+}
+
+// GetEnforceRulesEvaluated returns the enforce rules evaluated histogram
+// metric, or nil if EnforceRulesEvaluated is not set.
+func (p *PrometheusLogger) GetEnforceRulesEvaluated() *prometheus.HistogramVec {
+	return p.enforceRulesEvaluated
+}
+
+// GetInvalidEntries returns the invalid entries counter metric, or nil if
+// StrictEntries is not set.
+func (p *PrometheusLogger) GetInvalidEntries() prometheus.Counter {
+	return p.invalidEntries
+}
+
+// GetEnforceRetries returns the enforce retries counter metric, or nil if
+// EnforceRetries is not set.
+func (p *PrometheusLogger) GetEnforceRetries() *prometheus.CounterVec {
+	return p.enforceRetries
+}
+
+// GetEnforceMaxRetries returns the enforce max retries gauge metric, or nil
+// if EnforceRetries is not set.
+func (p *PrometheusLogger) GetEnforceMaxRetries() prometheus.Gauge {
+	return p.enforceMaxRetries
+}
+
+// GetEnforceDurationTotal returns the enforce duration total counter metric,
+// or nil if EnforceDurationTotal is not set.
+func (p *PrometheusLogger) GetEnforceDurationTotal() *prometheus.CounterVec {
+	return p.enforceDurationTotal
+}
+
+// GetEnforceWaitDuration returns the enforce wait duration histogram metric,
+// or nil if EnforceWaitEval is not set.
+func (p *PrometheusLogger) GetEnforceWaitDuration() *prometheus.HistogramVec {
+	return p.enforceWaitDuration
+}
+
+// GetEnforceEvalDuration returns the enforce evaluation duration histogram
+// metric, or nil if EnforceWaitEval is not set.
+func (p *PrometheusLogger) GetEnforceEvalDuration() *prometheus.HistogramVec {
+	return p.enforceEvalDuration
+}
+
+// GetPolicyReloadInterval returns the policy reload interval histogram
+// metric, or nil if PolicyReloadInterval is not set.
+func (p *PrometheusLogger) GetPolicyReloadInterval() prometheus.Histogram {
+	return p.policyReloadInterval
+}
+
+// GetEntryInconsistencies returns the entry inconsistencies counter metric,
+// or nil if StrictMode is not set.
+func (p *PrometheusLogger) GetEntryInconsistencies() prometheus.Counter {
+	return p.entryInconsistencies
+}
+
+// GetCallbackTimeouts returns the callback timeouts counter metric, or nil if
+// CallbackTimeout is not set.
+func (p *PrometheusLogger) GetCallbackTimeouts() prometheus.Counter {
+	return p.callbackTimeouts
+}
+
+// GetEnforceConsecutiveDenies returns the consecutive denies gauge metric, or
+// nil if EnforceConsecutiveDenies is not set.
+func (p *PrometheusLogger) GetEnforceConsecutiveDenies() *prometheus.GaugeVec {
+	return p.consecutiveDenies
+}
+
+// GetEnforceCPUDuration returns the enforce CPU time histogram metric, or nil
+// if EnforceCPU is not set.
+func (p *PrometheusLogger) GetEnforceCPUDuration() *prometheus.HistogramVec {
+	return p.enforceCPUDuration
+}
+
+// GetEnforceLatencyEWMA returns the enforce latency moving average gauge
+// metric, or nil if EnforceLatencyEWMAAlpha is not set.
+func (p *PrometheusLogger) GetEnforceLatencyEWMA() *prometheus.GaugeVec {
+	return p.enforceLatencyEWMA
+}
+
+// GetEnforceErrorRate returns the enforce error rate collector, or nil if
+// EnforceErrorRateWindow is not set.
+func (p *PrometheusLogger) GetEnforceErrorRate() prometheus.Collector {
+	if p.enforceErrorRate == nil {
+		return nil
+	}
+	return p.enforceErrorRate
+}
+
+// GetEnforceArgMismatch returns the enforce argument mismatch counter metric,
+// or nil if ExpectedArgCount is not set.
+func (p *PrometheusLogger) GetEnforceArgMismatch() prometheus.Counter {
+	return p.enforceArgMismatch
+}
+
+// GetEnforceDomainConcurrency returns the in-flight enforce requests gauge
+// metric, or nil if EnforceDomainConcurrency is not set.
+func (p *PrometheusLogger) GetEnforceDomainConcurrency() *prometheus.GaugeVec {
+	return p.domainConcurrency
+}
+
+// GetEnforceDomainConcurrencyMax returns the highest in-flight enforce
+// requests gauge metric, or nil if EnforceDomainConcurrency is not set.
+func (p *PrometheusLogger) GetEnforceDomainConcurrencyMax() *prometheus.GaugeVec {
+	return p.domainConcurrencyMax
+}
+
+// GetPolicyOperationErrors returns the policy operation errors counter
+// metric, or nil if PolicyOperationErrors is not set.
+func (p *PrometheusLogger) GetPolicyOperationErrors() *prometheus.CounterVec {
+	return p.policyOpErrors
+}
+
+// GetCallbackDuration returns the callback duration histogram metric, or nil
+// if CallbackDuration is not set.
+func (p *PrometheusLogger) GetCallbackDuration() prometheus.Histogram {
+	return p.callbackDuration
+}
+
+// GetEnforceDistinctSubjects returns the enforce distinct subjects collector,
+// or nil if EnforceDistinctSubjects is not set.
+func (p *PrometheusLogger) GetEnforceDistinctSubjects() prometheus.Collector {
+	if p.distinctSubjects == nil {
+		return nil
+	}
+	return p.distinctSubjects
+}
+
+// GetEnforceAllowRatio returns the enforce allow ratio collector, or nil if
+// EnforceAllowRatio is not set.
+func (p *PrometheusLogger) GetEnforceAllowRatio() prometheus.Collector {
+	if p.enforceAllowRatio == nil {
+		return nil
+	}
+	return p.enforceAllowRatio
+}
+
+// GetEnforceDenies returns the enforce denies by cause counter metric, or nil
+// if EnforceDenyCause is not set.
+func (p *PrometheusLogger) GetEnforceDenies() *prometheus.CounterVec {
+	return p.enforceDenies
+}
+
+// GetEnforceCacheHits returns the enforce cache hits counter metric, or nil
+// if EnforceCache is not set.
+func (p *PrometheusLogger) GetEnforceCacheHits() *prometheus.CounterVec {
+	return p.enforceCacheHits
+}
+
+// GetEnforceCacheMisses returns the enforce cache misses counter metric, or
+// nil if EnforceCache is not set.
+func (p *PrometheusLogger) GetEnforceCacheMisses() *prometheus.CounterVec {
+	return p.enforceCacheMisses
+}
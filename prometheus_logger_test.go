@@ -15,12 +15,20 @@
 package prometheuslogger
 
 import (
+	"context"
 	"errors"
+	"math"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestNewPrometheusLogger(t *testing.T) {
@@ -60,6 +68,10 @@ func TestNewPrometheusLogger(t *testing.T) {
 		t.Error("policyRulesCount metric not initialized")
 	}
 
+	if logger.policyStateCount == nil {
+		t.Error("policyStateCount metric not initialized")
+	}
+
 	// Clean up
 	logger.Unregister()
 }
@@ -92,6 +104,9 @@ func TestNewPrometheusLoggerWithRegistry(t *testing.T) {
 	if logger.policyRulesCount == nil {
 		t.Error("policyRulesCount not initialized")
 	}
+	if logger.policyStateCount == nil {
+		t.Error("policyStateCount not initialized")
+	}
 
 	// Clean up
 	logger.UnregisterFrom(registry)
@@ -287,6 +302,81 @@ func TestOnAfterEvent_PolicyOperation(t *testing.T) {
 	}
 }
 
+func TestOnAfterEvent_PolicyOperationSubtypes(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	for eventType, operation := range map[EventType]string{
+		EventAddPolicies:          "addPolicies",
+		EventRemovePolicies:       "removePolicies",
+		EventRemoveFilteredPolicy: "removeFilteredPolicy",
+		EventUpdatePolicy:         "updatePolicy",
+		EventUpdatePolicies:       "updatePolicies",
+	} {
+		if err := logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: eventType, StartTime: time.Now(), RuleCount: 2}); err != nil {
+			t.Fatalf("OnAfterEvent returned error: %v", err)
+		}
+		if got := testutil.ToFloat64(logger.policyOpsTotal.WithLabelValues(operation, "true")); got != 1 {
+			t.Errorf("Expected 1 %s operation, got %f", operation, got)
+		}
+	}
+
+	if count := testutil.CollectAndCount(logger.unknownEvents); count != 0 {
+		t.Errorf("Expected no unknown events, got %d", count)
+	}
+}
+
+func TestPolicyUpdateNetChange(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	for _, entry := range []*LogEntry{
+		{IsActive: true, EventType: EventUpdatePolicy, OldRuleCount: 2, NewRuleCount: 5},
+		{IsActive: true, EventType: EventUpdatePolicy, OldRuleCount: 4, NewRuleCount: 1},
+		{IsActive: true, EventType: EventUpdatePolicy, OldRuleCount: 3, NewRuleCount: 3},
+		{IsActive: true, EventType: EventUpdatePolicies, OldRuleCount: 1, NewRuleCount: 11},
+		{IsActive: true, EventType: EventUpdatePolicy, OldRuleCount: 1, NewRuleCount: 100, Error: errors.New("update failed")},
+		{IsActive: true, EventType: EventAddPolicy, RuleCount: 7},
+	} {
+		entry.StartTime = time.Now()
+		logger.OnAfterEvent(entry)
+	}
+
+	expected := `
+# HELP casbin_policy_update_net_change Change in the number of policy rules made by update operations
+# TYPE casbin_policy_update_net_change histogram
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="-1000"} 0
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="-100"} 0
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="-10"} 0
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="-1"} 0
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="0"} 0
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="1"} 0
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="10"} 1
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="100"} 1
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="1000"} 1
+casbin_policy_update_net_change_bucket{operation="updatePolicies",le="+Inf"} 1
+casbin_policy_update_net_change_sum{operation="updatePolicies"} 10
+casbin_policy_update_net_change_count{operation="updatePolicies"} 1
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="-1000"} 0
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="-100"} 0
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="-10"} 0
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="-1"} 1
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="0"} 2
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="1"} 2
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="10"} 3
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="100"} 3
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="1000"} 3
+casbin_policy_update_net_change_bucket{operation="updatePolicy",le="+Inf"} 3
+casbin_policy_update_net_change_sum{operation="updatePolicy"} 0
+casbin_policy_update_net_change_count{operation="updatePolicy"} 3
+`
+	if err := testutil.CollectAndCompare(logger.policyUpdateNet, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestOnAfterEvent_WithError(t *testing.T) {
 	registry := prometheus.NewRegistry()
 	logger := NewPrometheusLoggerWithRegistry(registry)
@@ -371,6 +461,139 @@ func TestSetLogCallback_WithError(t *testing.T) {
 	}
 }
 
+func TestPruneOnDisable(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{PruneOnDisable: true})
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventAddPolicy, StartTime: time.Now(), RuleCount: 1})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventLoadPolicy, StartTime: time.Now(), RuleCount: 5})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now()})
+
+	logger.SetEventTypes([]EventType{EventEnforce, EventLoadPolicy})
+
+	expected := `
+# HELP casbin_policy_operations_total Total number of policy operations
+# TYPE casbin_policy_operations_total counter
+casbin_policy_operations_total{operation="loadPolicy",success="true"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_policy_operations_total"); err != nil {
+		t.Error(err)
+	}
+	if count := testutil.CollectAndCount(logger.policyRulesCount); count != 1 {
+		t.Errorf("Expected the addPolicy rule count to be deleted, got %d series", count)
+	}
+	if count := testutil.CollectAndCount(logger.enforceTotal); count != 1 {
+		t.Errorf("Expected the enabled enforce series to be kept, got %d", count)
+	}
+
+	// Disabling enforce resets the enforce series.
+	logger.SetEventTypes([]EventType{EventLoadPolicy})
+	if count := testutil.CollectAndCount(logger.enforceTotal); count != 0 {
+		t.Errorf("Expected the enforce series to be deleted, got %d", count)
+	}
+}
+
+func TestPruneOnDisable_EventTypeLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels:  []string{LabelAllowed, LabelEventType},
+		PruneOnDisable: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now()})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforceEx, StartTime: time.Now()})
+	logger.SetEventTypes([]EventType{EventEnforce})
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="false",event_type="enforce"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPruneOnDisable_Disabled(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventAddPolicy, StartTime: time.Now()})
+	logger.SetEventTypes([]EventType{EventEnforce})
+
+	if count := testutil.CollectAndCount(logger.policyOpsTotal); count != 1 {
+		t.Errorf("Expected the series to be kept without PruneOnDisable, got %d", count)
+	}
+}
+
+func TestSetCallbackEventTypes(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	var called []EventType
+	logger.SetLogCallback(func(entry *LogEntry) error {
+		called = append(called, entry.EventType)
+		return nil
+	})
+	logger.SetCallbackEventTypes([]EventType{EventEnforce})
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now()})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventLoadPolicy, StartTime: time.Now()})
+
+	if !slices.Equal(called, []EventType{EventEnforce}) {
+		t.Errorf("Expected the callback for enforce only, got %v", called)
+	}
+	if value := testutil.ToFloat64(logger.GetPolicyOpsTotal().WithLabelValues("loadPolicy", "true")); value != 1 {
+		t.Errorf("Expected the skipped load policy to be recorded, got %v", value)
+	}
+
+	// An empty list restores the callback for every event type.
+	logger.SetCallbackEventTypes(nil)
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventLoadPolicy, StartTime: time.Now()})
+	if !slices.Equal(called, []EventType{EventEnforce, EventLoadPolicy}) {
+		t.Errorf("Expected the callback for every event type, got %v", called)
+	}
+}
+
+func TestSetLogCallbackWithFilter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	var called []string
+	logger.SetLogCallbackWithFilter(
+		func(entry *LogEntry) bool { return !entry.Allowed },
+		func(entry *LogEntry) error {
+			called = append(called, entry.Subject)
+			return nil
+		},
+	)
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), Subject: "alice", Allowed: true})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), Subject: "bob"})
+
+	if !slices.Equal(called, []string{"bob"}) {
+		t.Errorf("Expected the callback for the denial only, got %v", called)
+	}
+	if value := testutil.ToFloat64(logger.enforceTotal.WithLabelValues("true", "default")); value != 1 {
+		t.Errorf("Expected the filtered entry to be recorded, got %v", value)
+	}
+
+	// SetLogCallback removes the filter.
+	logger.SetLogCallback(func(entry *LogEntry) error {
+		called = append(called, entry.Subject)
+		return nil
+	})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), Subject: "carol", Allowed: true})
+	if !slices.Equal(called, []string{"bob", "carol"}) {
+		t.Errorf("Expected the callback without a filter, got %v", called)
+	}
+}
+
 func TestEnforceMetrics_DifferentDomains(t *testing.T) {
 	registry := prometheus.NewRegistry()
 	logger := NewPrometheusLoggerWithRegistry(registry)
@@ -429,6 +652,10 @@ func TestMetricGetters(t *testing.T) {
 	if logger.GetPolicyRulesCount() == nil {
 		t.Error("GetPolicyRulesCount returned nil")
 	}
+
+	if logger.GetPolicyStateCount() == nil {
+		t.Error("GetPolicyStateCount returned nil")
+	}
 }
 
 func TestLogger_InterfaceImplementation(t *testing.T) {
@@ -490,3 +717,2230 @@ func TestFullWorkflow(t *testing.T) {
 		t.Errorf("Expected 0 policy metrics (filtered), got %d", policyCount)
 	}
 }
+
+func TestNewPrometheusLoggerWithOptions(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelSubject, LabelObject, LabelAction},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		StartTime: time.Now(),
+		Subject:   "alice",
+		Object:    "data1",
+		Action:    "read",
+		Allowed:   true,
+	})
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{action="read",object="data1",subject="alice"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+
+	if logger.GetEnforceSeriesOverflow() != nil {
+		t.Error("Overflow counter should not exist without MaxSeries")
+	}
+}
+
+func TestNewPrometheusLoggerWithOptions_Invalid(t *testing.T) {
+	invalid := []*PrometheusLoggerOptions{
+		{EnforceLabels: []string{}},
+		{EnforceLabels: []string{"tenant"}},
+		{EnforceLabels: []string{LabelSubject, LabelSubject}},
+		{MaxSeries: -1},
+		{SLOThresholds: map[string]time.Duration{"read": 0}},
+		{PolicySuccessLabelName: "1status"},
+		{PolicySuccessLabelName: "__status"},
+		{PolicySuccessLabelName: "operation"},
+		{PolicySuccessLabelName: "adapter"},
+		{PolicySuccessValue: "false"},
+		{DurationUnit: "minutes"},
+		{Namespace: "my-app"},
+		{MetricNames: map[string]string{"casbin_enforce_total": "authz_total"}},
+		{MetricNames: map[string]string{"enforce_total": "authz-total"}},
+		{MetricNames: map[string]string{"enforce_total": "authz_total", "policy_operations_total": "authz_total"}},
+		{MetricHelp: map[string]string{"casbin_enforce_total": "Enforce requests"}},
+		{EnforceDurationBuckets: []float64{}},
+		{EnforceDurationBuckets: []float64{0.1, 0.1}},
+		{EnforceDurationBuckets: []float64{0.1}, CountOnlyDuration: true},
+		{CallbackTimeout: -time.Second},
+		{EnforceLatencyEWMAAlpha: 1.5},
+		{EnforceErrorRateWindow: -time.Minute},
+		{ExpectedArgCount: -1},
+		{DecisionLogSize: -1},
+		{PolicySources: []string{"file", ""}},
+		{PolicySources: []string{"file"}, PolicySuccessLabelName: "source"},
+		{EnabledMetrics: []string{"casbin_enforce_total"}},
+	}
+
+	for _, options := range invalid {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected panic for options %+v", options)
+				}
+			}()
+			NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), options)
+		}()
+	}
+}
+
+func TestEnforceByHour(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	location := time.FixedZone("UTC+2", 2*60*60)
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceByHour:         true,
+		EnforceByHourLocation: location,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	now := time.Date(2026, 1, 1, 1, 30, 0, 0, time.UTC)
+	logger.now = func() time.Time { return now }
+
+	record := func(allowed bool) {
+		entry := &LogEntry{EventType: EventEnforce, Allowed: allowed}
+		logger.OnBeforeEvent(entry)
+		logger.OnAfterEvent(entry)
+	}
+
+	// 01:30 UTC is 03:30 in UTC+2
+	record(true)
+	record(false)
+
+	// 22:15 UTC is 00:15 in UTC+2
+	now = time.Date(2026, 1, 1, 22, 15, 0, 0, time.UTC)
+	record(false)
+
+	byHour := logger.GetEnforceByHour()
+	if value := testutil.ToFloat64(byHour.WithLabelValues("3", "true")); value != 1 {
+		t.Errorf("Expected 1 allowed request at hour 3, got %v", value)
+	}
+	if value := testutil.ToFloat64(byHour.WithLabelValues("3", "false")); value != 1 {
+		t.Errorf("Expected 1 denied request at hour 3, got %v", value)
+	}
+	if value := testutil.ToFloat64(byHour.WithLabelValues("0", "false")); value != 1 {
+		t.Errorf("Expected 1 denied request at hour 0, got %v", value)
+	}
+}
+
+func TestRecordEnforceResults(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelSubject, LabelObject},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	base := &LogEntry{
+		EventType: EventEnforce,
+		Subject:   "alice",
+		Action:    "read",
+		Domain:    "domain1",
+	}
+	logger.OnBeforeEvent(base)
+	base.StartTime = base.StartTime.Add(-10 * time.Millisecond)
+
+	logger.RecordEnforceResults(base, []EnforceResult{
+		{Object: "data1", Allowed: true},
+		{Object: "data2", Allowed: false},
+		{Object: "data3", Allowed: true},
+	})
+
+	if base.Duration < 10*time.Millisecond {
+		t.Errorf("Expected the base duration to be measured, got %v", base.Duration)
+	}
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="false",object="data2",subject="alice"} 1
+casbin_enforce_total{allowed="true",object="data1",subject="alice"} 1
+casbin_enforce_total{allowed="true",object="data3",subject="alice"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+
+	if count := testutil.CollectAndCount(logger.enforceDuration); count != 3 {
+		t.Errorf("Expected 3 enforce duration series, got %d", count)
+	}
+
+	// Inactive entries record nothing
+	logger.RecordEnforceResults(&LogEntry{EventType: EventEnforce}, []EnforceResult{{Object: "data4"}})
+	if count := testutil.CollectAndCount(logger.enforceTotal); count != 3 {
+		t.Errorf("Expected inactive entry to be ignored, got %d series", count)
+	}
+}
+
+func TestRecordEnforceResults_LikeOnAfterEvent(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels:            []string{LabelAllowed, LabelDomain, LabelObject},
+		EnforceDomainConcurrency: true,
+		StrictEntries:            true,
+	})
+	defer logger.UnregisterFrom(registry)
+	observed := 0
+	if err := logger.AddObserver("app_results", nil, func(*LogEntry) (float64, bool) {
+		observed++
+		return 1, true
+	}); err != nil {
+		t.Fatalf("AddObserver failed: %v", err)
+	}
+
+	base := &LogEntry{EventType: EventEnforce, Domain: "domain1"}
+	logger.OnBeforeEvent(base)
+	if err := logger.RecordEnforceResults(base, []EnforceResult{{Object: "data1", Allowed: true}, {Object: "data2"}}); err != nil {
+		t.Fatalf("RecordEnforceResults failed: %v", err)
+	}
+	if value := testutil.ToFloat64(logger.domainConcurrency.WithLabelValues("domain1")); value != 0 {
+		t.Errorf("Expected no enforce requests in flight, got %v", value)
+	}
+	if observed != 2 {
+		t.Errorf("Expected 2 observations, got %d", observed)
+	}
+
+	// An invalid result fails the whole batch.
+	base = &LogEntry{EventType: EventEnforce, Domain: "domain1"}
+	logger.OnBeforeEvent(base)
+	err := logger.RecordEnforceResults(base, []EnforceResult{{Object: "data3"}, {Object: ""}})
+	if !errors.Is(err, ErrInvalidEntry) {
+		t.Errorf("Expected ErrInvalidEntry, got %v", err)
+	}
+	if count := testutil.CollectAndCount(logger.GetEnforceTotal()); count != 2 {
+		t.Errorf("Expected no series from the invalid batch, got %d series", count)
+	}
+	if value := testutil.ToFloat64(logger.domainConcurrency.WithLabelValues("domain1")); value != 0 {
+		t.Errorf("Expected the invalid batch to leave no request in flight, got %v", value)
+	}
+}
+
+func TestNormalizeLabelValues(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		NormalizeLabelValues: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, domain := range []string{"Org1 ", "org1", " ORG1"} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventEnforce,
+			StartTime: time.Now(),
+			Domain:    domain,
+			Allowed:   true,
+		})
+	}
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",domain="org1"} 3
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestObjectGrouper(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelObject},
+		ObjectGrouper: PathTemplateGrouper,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, object := range []string{
+		"/users/42",
+		"/users/7",
+		"/users/3f2504e0-4f89-11d3-9a0c-0305e82c3301",
+		"/users/42/posts/1?page=2",
+		"/users/me",
+	} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventEnforce,
+			StartTime: time.Now(),
+			Object:    object,
+			Allowed:   true,
+		})
+	}
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",object="/users/me"} 1
+casbin_enforce_total{allowed="true",object="/users/{id}"} 3
+casbin_enforce_total{allowed="true",object="/users/{id}/posts/{id}"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPathTemplateGrouper(t *testing.T) {
+	testCases := map[string]string{
+		"/":                 "/",
+		"/users":            "/users",
+		"/users/":           "/users/",
+		"/orgs/12/users/34": "/orgs/{id}/users/{id}",
+		"/v1/items/A1B2":    "/v1/items/A1B2",
+		"data1":             "data1",
+		"/files/123abc":     "/files/123abc",
+		"/users/3F2504E0-4F89-11D3-9A0C-0305E82C3301": "/users/{id}",
+	}
+	for object, expected := range testCases {
+		if got := PathTemplateGrouper(object); got != expected {
+			t.Errorf("PathTemplateGrouper(%q) = %q, expected %q", object, got, expected)
+		}
+	}
+}
+
+func TestActionClassifier(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelAction},
+		ActionClassifier: func(action string) string {
+			switch action {
+			case "GET", "HEAD", "OPTIONS":
+				return "read"
+			case "POST", "PUT", "PATCH", "DELETE":
+				return "write"
+			default:
+				return "other"
+			}
+		},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, action := range []string{"GET", "HEAD", "GET", "POST", "DELETE", "PURGE"} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventEnforce,
+			StartTime: time.Now(),
+			Action:    action,
+			Allowed:   true,
+		})
+	}
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{action="other",allowed="true"} 1
+casbin_enforce_total{action="read",allowed="true"} 3
+casbin_enforce_total{action="write",allowed="true"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNormalizeLabelValues_Disabled(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	for _, domain := range []string{"Org1 ", "org1"} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventEnforce,
+			StartTime: time.Now(),
+			Domain:    domain,
+		})
+	}
+
+	if count := testutil.CollectAndCount(logger.enforceTotal); count != 2 {
+		t.Errorf("Expected label values to be kept as-is by default, got %d series", count)
+	}
+}
+
+func TestSanitizeUTF8(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelDomain, LabelSubject},
+		SanitizeUTF8:  true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		StartTime: time.Now(),
+		Domain:    "org1\xc3",
+		Subject:   "user\xff\xfe1",
+		Allowed:   true,
+	})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather failed: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() != "casbin_enforce_total" {
+			continue
+		}
+		for _, label := range family.GetMetric()[0].GetLabel() {
+			if !utf8.ValidString(label.GetValue()) {
+				t.Errorf("Expected the %s label value to be valid UTF-8, got %q", label.GetName(), label.GetValue())
+			}
+		}
+	}
+	if value := testutil.ToFloat64(logger.GetEnforceTotal().WithLabelValues("true", "org1\uFFFD", "user\uFFFD1")); value != 1 {
+		t.Errorf("Expected the invalid sequences to be replaced, got %v", value)
+	}
+}
+
+func TestUpdatePolicyState(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.UpdatePolicyState("p", 10)
+	logger.UpdatePolicyState("g", 3)
+	logger.UpdatePolicyState("p", 12)
+
+	if value := testutil.ToFloat64(logger.GetPolicyStateCount().WithLabelValues("p")); value != 12 {
+		t.Errorf("Expected 12 p rules, got %v", value)
+	}
+	if value := testutil.ToFloat64(logger.GetPolicyStateCount().WithLabelValues("g")); value != 3 {
+		t.Errorf("Expected 3 g rules, got %v", value)
+	}
+}
+
+func TestUpdatePolicyStateBulk(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.UpdatePolicyStateBulk(map[string]int{"p": 10, "g": 3, "g2": 1}, true)
+	if count := testutil.CollectAndCount(logger.policyStateCount); count != 3 {
+		t.Fatalf("Expected 3 policy state series, got %d", count)
+	}
+
+	// Without pruning, missing ptypes are kept
+	logger.UpdatePolicyStateBulk(map[string]int{"p": 11}, false)
+	if count := testutil.CollectAndCount(logger.policyStateCount); count != 3 {
+		t.Errorf("Expected 3 policy state series without pruning, got %d", count)
+	}
+
+	// With pruning, the dropped g2 ptype is removed
+	logger.UpdatePolicyStateBulk(map[string]int{"p": 12, "g": 4}, true)
+
+	expected := `
+# HELP casbin_policy_state_count Current number of policy rules by ptype
+# TYPE casbin_policy_state_count gauge
+casbin_policy_state_count{ptype="g"} 4
+casbin_policy_state_count{ptype="p"} 12
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_policy_state_count"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReset(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", Allowed: true})
+	logger.UpdatePolicyStateBulk(map[string]int{"p": 10, "g": 3}, true)
+	logger.Reset()
+
+	if count := testutil.CollectAndCount(logger.GetEnforceTotal()); count != 0 {
+		t.Errorf("Expected no enforce series after Reset, got %d", count)
+	}
+	if count := testutil.CollectAndCount(logger.GetPolicyStateCount()); count != 0 {
+		t.Errorf("Expected no policy state series after Reset, got %d", count)
+	}
+
+	// Ptypes set before the reset are not pruned by a later snapshot.
+	logger.UpdatePolicyStateBulk(map[string]int{"p": 11}, true)
+	if count := testutil.CollectAndCount(logger.GetPolicyStateCount()); count != 1 {
+		t.Errorf("Expected 1 policy state series, got %d", count)
+	}
+}
+
+func TestReset_ConcurrentUpdatePolicyState(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			logger.UpdatePolicyState("p", i)
+			logger.UpdatePolicyStateBulk(map[string]int{"g": i}, i%2 == 0)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			logger.Reset()
+		}
+	}()
+	wg.Wait()
+
+	// The last update wins once the updates stop.
+	logger.UpdatePolicyState("p", 5)
+	if value := testutil.ToFloat64(logger.GetPolicyStateCount().WithLabelValues("p")); value != 5 {
+		t.Errorf("Expected 5 p rules, got %v", value)
+	}
+}
+
+func TestEnforceWeighted(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceWeighted: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, weight := range []float64{1, 2, 0.5} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventEnforce,
+			StartTime: time.Now(),
+			Domain:    "tenant1",
+			Weight:    weight,
+		})
+	}
+
+	// An unset weight counts as 1
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		StartTime: time.Now(),
+		Domain:    "tenant2",
+	})
+
+	if value := testutil.ToFloat64(logger.GetEnforceWeighted().WithLabelValues("tenant1")); value != 3.5 {
+		t.Errorf("Expected weighted total 3.5 for tenant1, got %v", value)
+	}
+	if value := testutil.ToFloat64(logger.GetEnforceWeighted().WithLabelValues("tenant2")); value != 1 {
+		t.Errorf("Expected weighted total 1 for tenant2, got %v", value)
+	}
+}
+
+func TestNewPrometheusLogger_Twice(t *testing.T) {
+	first := NewPrometheusLogger()
+	second := NewPrometheusLogger()
+	defer first.Unregister()
+
+	if second.enforceTotal != first.enforceTotal {
+		t.Error("Second logger should adopt the registered enforceTotal metric")
+	}
+	if second.policyStateCount != first.policyStateCount {
+		t.Error("Second logger should adopt the registered policyStateCount metric")
+	}
+
+	second.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		StartTime: time.Now(),
+		Domain:    "twice",
+		Allowed:   true,
+	})
+	if value := testutil.ToFloat64(first.enforceTotal.WithLabelValues("true", "twice")); value != 1 {
+		t.Errorf("Expected shared enforce counter to be 1, got %v", value)
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	const goroutines = 50
+	loggers := make([]*PrometheusLogger, goroutines)
+
+	var wg sync.WaitGroup
+	for i := range loggers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			loggers[i] = DefaultLogger()
+		}()
+	}
+	wg.Wait()
+
+	if loggers[0] == nil {
+		t.Fatal("DefaultLogger returned nil")
+	}
+	for i, logger := range loggers {
+		if logger != loggers[0] {
+			t.Fatalf("goroutine %d got a different logger", i)
+		}
+	}
+	if DefaultLogger() != loggers[0] {
+		t.Error("expected later calls to return the shared logger")
+	}
+}
+
+func TestNewPrometheusLoggerWithOptions_DefaultRegistryTwice(t *testing.T) {
+	options := &PrometheusLoggerOptions{MaxSeries: 10}
+	first := NewPrometheusLoggerWithOptions(nil, options)
+	second := NewPrometheusLoggerWithOptions(nil, options)
+	defer first.Unregister()
+
+	if second.enforceSeriesOverflow != first.enforceSeriesOverflow {
+		t.Error("Second logger should adopt the registered overflow counter")
+	}
+}
+
+func TestMetricNames(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	expected := []string{
+		"casbin_enforce_duration_seconds",
+		"casbin_enforce_total",
+		"casbin_events_filtered_total",
+		"casbin_internal_errors_total",
+		"casbin_model_load_duration_seconds",
+		"casbin_model_load_total",
+		"casbin_policy_operations_duration_seconds",
+		"casbin_policy_operations_total",
+		"casbin_policy_rules_count",
+		"casbin_policy_state_count",
+		"casbin_policy_update_net_change",
+		"casbin_role_closure_size",
+		"casbin_unknown_events_total",
+	}
+	names := logger.MetricNames()
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected metric names %v, got %v", expected, names)
+	}
+}
+
+func TestMetricNames_OptionalMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		MaxSeries:       10,
+		EnforceByHour:   true,
+		EnforceWeighted: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	names := logger.MetricNames()
+	for _, name := range []string{
+		"casbin_enforce_total",
+		"casbin_policy_state_count",
+		"casbin_enforce_series_overflow_total",
+		"casbin_logger_series_total",
+		"casbin_enforce_by_hour_total",
+		"casbin_enforce_weighted_total",
+	} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Expected %s in metric names %v", name, names)
+		}
+	}
+	if len(names) != 17 {
+		t.Errorf("Expected 17 metric names, got %d: %v", len(names), names)
+	}
+}
+
+func TestOnAfterEvent_UnknownEventType(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	callbackCalled := false
+	logger.SetLogCallback(func(entry *LogEntry) error {
+		callbackCalled = true
+		return nil
+	})
+
+	for i := 0; i < 2; i++ {
+		err := logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventType("madeUpEvent"),
+			StartTime: time.Now(),
+		})
+		if err != nil {
+			t.Errorf("OnAfterEvent returned error: %v", err)
+		}
+	}
+
+	if value := testutil.ToFloat64(logger.GetUnknownEvents().WithLabelValues("madeUpEvent")); value != 2 {
+		t.Errorf("Expected 2 unknown events, got %v", value)
+	}
+	if !callbackCalled {
+		t.Error("Callback should still be called for unknown event types")
+	}
+
+	// Known event types are not counted
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now()})
+	if count := testutil.CollectAndCount(logger.GetUnknownEvents()); count != 1 {
+		t.Errorf("Expected 1 unknown event series, got %d", count)
+	}
+}
+
+func TestRegisterEventType(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	if _, err := ParseEventType("preCheck"); err == nil {
+		t.Error("Expected an error parsing an unregistered event type")
+	}
+	eventPreCheck := RegisterEventType("preCheck")
+	if eventType, err := ParseEventType("preCheck"); err != nil || eventType != eventPreCheck {
+		t.Errorf("Expected to parse the registered event type, got %q, %v", eventType, err)
+	}
+	if eventType, err := ParseEventType("addPolicy"); err != nil || eventType != EventAddPolicy {
+		t.Errorf("Expected to parse a built-in event type, got %q, %v", eventType, err)
+	}
+	if eventPreCheck.String() != "preCheck" {
+		t.Errorf("Expected String to return the name, got %q", eventPreCheck.String())
+	}
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: eventPreCheck, StartTime: time.Now(), RuleCount: 3})
+
+	if value := testutil.ToFloat64(logger.policyOpsTotal.WithLabelValues("preCheck", "true")); value != 1 {
+		t.Errorf("Expected 1 preCheck operation, got %v", value)
+	}
+	if value := testutil.ToFloat64(logger.policyRulesCount.WithLabelValues("preCheck")); value != 3 {
+		t.Errorf("Expected a rule count of 3, got %v", value)
+	}
+	if count := testutil.CollectAndCount(logger.unknownEvents); count != 0 {
+		t.Errorf("Expected no unknown events, got %d", count)
+	}
+}
+
+func TestPolicyOpSuccessRate(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	if rate := logger.PolicyOpSuccessRate(EventAddPolicy); !math.IsNaN(rate) {
+		t.Errorf("Expected NaN without data, got %v", rate)
+	}
+
+	for i := 0; i < 10; i++ {
+		entry := &LogEntry{
+			IsActive:  true,
+			EventType: EventAddPolicy,
+			StartTime: time.Now(),
+		}
+		if i < 2 {
+			entry.Error = errors.New("adapter error")
+		}
+		logger.OnAfterEvent(entry)
+	}
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventRemovePolicy,
+		StartTime: time.Now(),
+		Error:     errors.New("adapter error"),
+	})
+
+	if rate := logger.PolicyOpSuccessRate(EventAddPolicy); rate != 0.8 {
+		t.Errorf("Expected AddPolicy success rate 0.8, got %v", rate)
+	}
+	if rate := logger.PolicyOpSuccessRate(EventRemovePolicy); rate != 0 {
+		t.Errorf("Expected RemovePolicy success rate 0, got %v", rate)
+	}
+}
+
+func TestUpdateRoleClosureSize(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.UpdateRoleClosureSize("g", 42)
+	logger.UpdateRoleClosureSize("g2", 7)
+
+	expected := `
+# HELP casbin_role_closure_size Number of effective role edges after transitive expansion by ptype
+# TYPE casbin_role_closure_size gauge
+casbin_role_closure_size{ptype="g"} 42
+casbin_role_closure_size{ptype="g2"} 7
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_role_closure_size"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSLOThresholds(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		SLOThresholds: map[string]time.Duration{
+			"read":  10 * time.Millisecond,
+			"write": 50 * time.Millisecond,
+		},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	record := func(action string, duration time.Duration) {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventEnforce,
+			StartTime: time.Now().Add(-duration),
+			Action:    action,
+		})
+	}
+
+	record("read", 20*time.Millisecond)   // violation
+	record("write", 20*time.Millisecond)  // compliant
+	record("delete", 20*time.Millisecond) // no threshold
+
+	violations := logger.GetEnforceSLOViolations()
+	if value := testutil.ToFloat64(violations.WithLabelValues("read")); value != 1 {
+		t.Errorf("Expected 1 read SLO violation, got %v", value)
+	}
+	if count := testutil.CollectAndCount(violations); count != 1 {
+		t.Errorf("Expected only the read action to violate its SLO, got %d series", count)
+	}
+}
+
+func TestSingleShotEnforce(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelDomain, LabelSubject},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	logger.SingleShotEnforce("alice", "data1", "read", "domain1", true)
+	logger.SingleShotEnforce("alice", "data2", "read", "domain1", true)
+
+	if value := testutil.ToFloat64(logger.enforceTotal.WithLabelValues("true", "domain1", "alice")); value != 2 {
+		t.Errorf("Expected 2 single-shot enforce requests, got %v", value)
+	}
+	if count := testutil.CollectAndCount(logger.enforceDuration); count != 0 {
+		t.Errorf("Expected no enforce duration samples, got %d", count)
+	}
+
+	// Filtered out when enforce events are disabled
+	logger.SetEventTypes([]EventType{EventAddPolicy})
+	logger.SingleShotEnforce("bob", "data1", "read", "domain1", false)
+	if count := testutil.CollectAndCount(logger.enforceTotal); count != 1 {
+		t.Errorf("Expected filtered single-shot enforce to be dropped, got %d series", count)
+	}
+}
+
+func TestAddEnforceCounts(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.AddEnforceCounts("tenant1", 100, 50)
+	logger.AddEnforceCounts("tenant1", 0, 1)
+
+	if value := testutil.ToFloat64(logger.enforceTotal.WithLabelValues("true", "tenant1")); value != 100 {
+		t.Errorf("Expected 100 allowed requests, got %v", value)
+	}
+	if value := testutil.ToFloat64(logger.enforceTotal.WithLabelValues("false", "tenant1")); value != 51 {
+		t.Errorf("Expected 51 denied requests, got %v", value)
+	}
+	if count := testutil.CollectAndCount(logger.enforceDuration); count != 0 {
+		t.Errorf("Expected no duration to be observed, got %d series", count)
+	}
+
+	logger.SetEventTypes([]EventType{EventAddPolicy})
+	logger.AddEnforceCounts("tenant1", 10, 10)
+	if value := testutil.ToFloat64(logger.enforceTotal.WithLabelValues("true", "tenant1")); value != 100 {
+		t.Errorf("Expected disabled enforce events not to be recorded, got %v", value)
+	}
+}
+
+func TestEnforceRulesEvaluated(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceRulesEvaluated: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, evaluated := range []int{3, 40, 2000, 0} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:       true,
+			EventType:      EventEnforce,
+			StartTime:      time.Now(),
+			Domain:         "domain1",
+			RulesEvaluated: evaluated,
+		})
+	}
+
+	expected := `
+# HELP casbin_enforce_rules_evaluated Number of policy rules evaluated per enforce request
+# TYPE casbin_enforce_rules_evaluated histogram
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="1"} 0
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="5"} 1
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="10"} 1
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="50"} 2
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="100"} 2
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="500"} 2
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="1000"} 2
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="5000"} 3
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="10000"} 3
+casbin_enforce_rules_evaluated_bucket{domain="domain1",le="+Inf"} 3
+casbin_enforce_rules_evaluated_sum{domain="domain1"} 2043
+casbin_enforce_rules_evaluated_count{domain="domain1"} 3
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_rules_evaluated"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPolicyPtypeLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		PolicyPtypeLabel: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, ptype := range []string{"p", "g", "p"} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventAddPolicy,
+			StartTime: time.Now(),
+			Ptype:     ptype,
+			RuleCount: 1,
+		})
+	}
+
+	expected := `
+# HELP casbin_policy_operations_total Total number of policy operations
+# TYPE casbin_policy_operations_total counter
+casbin_policy_operations_total{operation="addPolicy",ptype="g",success="true"} 1
+casbin_policy_operations_total{operation="addPolicy",ptype="p",success="true"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_policy_operations_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPolicyAdapterLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		PolicyAdapterLabel: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, adapter := range []string{"file", "mysql", "mysql"} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventSavePolicy,
+			StartTime: time.Now(),
+			Adapter:   adapter,
+		})
+	}
+
+	expected := `
+# HELP casbin_policy_operations_total Total number of policy operations
+# TYPE casbin_policy_operations_total counter
+casbin_policy_operations_total{adapter="file",operation="savePolicy",success="true"} 1
+casbin_policy_operations_total{adapter="mysql",operation="savePolicy",success="true"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_policy_operations_total"); err != nil {
+		t.Error(err)
+	}
+	if count := testutil.CollectAndCount(logger.GetPolicyOpsDuration()); count != 2 {
+		t.Errorf("Expected 2 policy duration series, got %d", count)
+	}
+}
+
+func TestPolicySourceLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		PolicySources: []string{"file", "watcher"},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, source := range []string{"file", "watcher", "watcher", "", "db"} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventLoadPolicy,
+			StartTime: time.Now(),
+			Source:    source,
+		})
+	}
+
+	expected := `
+# HELP casbin_policy_operations_total Total number of policy operations
+# TYPE casbin_policy_operations_total counter
+casbin_policy_operations_total{operation="loadPolicy",source="file",success="true"} 1
+casbin_policy_operations_total{operation="loadPolicy",source="other",success="true"} 1
+casbin_policy_operations_total{operation="loadPolicy",source="unknown",success="true"} 1
+casbin_policy_operations_total{operation="loadPolicy",source="watcher",success="true"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_policy_operations_total"); err != nil {
+		t.Error(err)
+	}
+	if count := testutil.CollectAndCount(logger.GetPolicyOpsDuration()); count != 4 {
+		t.Errorf("Expected 4 policy duration series, got %d", count)
+	}
+}
+
+func TestEventTypeLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelEventType},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, eventType := range []EventType{EventEnforce, EventEnforceEx, EventEnforceEx} {
+		entry := &LogEntry{EventType: eventType, Allowed: true}
+		logger.OnBeforeEvent(entry)
+		logger.OnAfterEvent(entry)
+	}
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",event_type="enforce"} 1
+casbin_enforce_total{allowed="true",event_type="enforceEx"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+	if count := testutil.CollectAndCount(logger.enforceDuration); count != 2 {
+		t.Errorf("Expected separate duration series, got %d", count)
+	}
+
+	// Disabling explained enforces skips them.
+	logger.SetEventTypes([]EventType{EventEnforce})
+	entry := &LogEntry{EventType: EventEnforceEx, Allowed: true}
+	logger.OnBeforeEvent(entry)
+	logger.OnAfterEvent(entry)
+	if value := testutil.ToFloat64(logger.enforceTotal.WithLabelValues("true", "enforceEx")); value != 2 {
+		t.Errorf("Expected the disabled event type not to be recorded, got %v", value)
+	}
+}
+
+func TestOutcomeLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelOutcome, LabelDomain},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	entries := []*LogEntry{
+		{Allowed: true},
+		{Allowed: false},
+		{Allowed: false, Error: errors.New("matcher error")},
+	}
+	for _, entry := range entries {
+		entry.IsActive = true
+		entry.EventType = EventEnforce
+		entry.StartTime = time.Now()
+		logger.OnAfterEvent(entry)
+	}
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{domain="default",outcome="allow"} 1
+casbin_enforce_total{domain="default",outcome="deny"} 1
+casbin_enforce_total{domain="default",outcome="error"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestResetEnforceMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{MaxSeries: 1})
+	defer logger.UnregisterFrom(registry)
+
+	for _, entry := range []*LogEntry{
+		{IsActive: true, EventType: EventEnforce, Domain: "domain1", Allowed: true},
+		{IsActive: true, EventType: EventAddPolicy, RuleCount: 1},
+	} {
+		entry.StartTime = time.Now()
+		logger.OnAfterEvent(entry)
+	}
+
+	logger.ResetEnforceMetrics()
+
+	if count := testutil.CollectAndCount(logger.GetEnforceTotal()); count != 0 {
+		t.Errorf("expected no enforce total series after reset, got %d", count)
+	}
+	if count := testutil.CollectAndCount(logger.GetEnforceDuration()); count != 0 {
+		t.Errorf("expected no enforce duration series after reset, got %d", count)
+	}
+	if value := testutil.ToFloat64(logger.GetPolicyOpsTotal().WithLabelValues("addPolicy", "true")); value != 1 {
+		t.Errorf("expected policy operations to survive reset, got %v", value)
+	}
+
+	// The series cap is freed by the reset.
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain2", StartTime: time.Now()})
+	if value := testutil.ToFloat64(logger.GetEnforceTotal().WithLabelValues("false", "domain2")); value != 1 {
+		t.Errorf("expected domain2 to be recorded after reset, got %v", value)
+	}
+}
+
+func TestDefaultLabelsFastPath(t *testing.T) {
+	fastRegistry := prometheus.NewRegistry()
+	fast := NewPrometheusLoggerWithRegistry(fastRegistry)
+	defer fast.UnregisterFrom(fastRegistry)
+	if !fast.defaultLabelsFastPath {
+		t.Fatal("expected the default labels to use the fast path")
+	}
+
+	generalRegistry := prometheus.NewRegistry()
+	general := NewPrometheusLoggerWithRegistry(generalRegistry)
+	defer general.UnregisterFrom(generalRegistry)
+	general.defaultLabelsFastPath = false
+
+	entries := []*LogEntry{
+		{Domain: "domain1", Allowed: true, Duration: time.Millisecond},
+		{Domain: "domain1", Allowed: false, Duration: 2 * time.Millisecond},
+		{Allowed: true, Duration: 3 * time.Millisecond},
+	}
+	for _, entry := range entries {
+		entry.EventType = EventEnforce
+		fast.recordEnforceMetrics(context.Background(), entry)
+		general.recordEnforceMetrics(context.Background(), entry)
+	}
+
+	var fastCSV, generalCSV strings.Builder
+	if err := fast.ExportCSV(&fastCSV); err != nil {
+		t.Fatal(err)
+	}
+	if err := general.ExportCSV(&generalCSV); err != nil {
+		t.Fatal(err)
+	}
+	if fastCSV.String() != generalCSV.String() {
+		t.Errorf("fast path and general path differ:\n%s\n%s", fastCSV.String(), generalCSV.String())
+	}
+}
+
+func TestDefaultLabelsFastPath_ModifiedDefaultEnforceLabels(t *testing.T) {
+	saved := slices.Clone(DefaultEnforceLabels)
+	defer copy(DefaultEnforceLabels, saved)
+	DefaultEnforceLabels[0], DefaultEnforceLabels[1] = LabelDomain, LabelAllowed
+
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	if !logger.defaultLabelsFastPath {
+		t.Fatal("expected the default labels to use the fast path")
+	}
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", Allowed: true, StartTime: time.Now()})
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",domain="domain1"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDefaultLabelsFastPath_Disabled(t *testing.T) {
+	for name, options := range map[string]*PrometheusLoggerOptions{
+		"custom labels": {EnforceLabels: []string{LabelAllowed, LabelDomain, LabelSubject}},
+		"max series":    {MaxSeries: 10},
+		"idle ttl":      {IdleSeriesTTL: time.Hour},
+	} {
+		registry := prometheus.NewRegistry()
+		logger := NewPrometheusLoggerWithOptions(registry, options)
+		if logger.defaultLabelsFastPath {
+			t.Errorf("%s: expected the general path", name)
+		}
+		logger.Close()
+		logger.UnregisterFrom(registry)
+	}
+}
+
+func TestLogEntryValidate(t *testing.T) {
+	labels := []string{LabelAllowed, LabelSubject, LabelAction}
+	tests := []struct {
+		name  string
+		entry *LogEntry
+		valid bool
+	}{
+		{"complete enforce", &LogEntry{EventType: EventEnforce, Subject: "alice", Action: "read"}, true},
+		{"enforce without subject", &LogEntry{EventType: EventEnforce, Action: "read"}, false},
+		{"enforce without action", &LogEntry{EventType: EventEnforce, Subject: "alice"}, false},
+		{"enforce without unlabeled object", &LogEntry{EventType: EventEnforce, Subject: "alice", Action: "read"}, true},
+		{"enforce with negative rules evaluated", &LogEntry{EventType: EventEnforce, Subject: "alice", Action: "read", RulesEvaluated: -1}, false},
+		{"policy with negative rule count", &LogEntry{EventType: EventAddPolicy, RuleCount: -1}, false},
+		{"policy with rule count", &LogEntry{EventType: EventLoadPolicy, RuleCount: 3}, true},
+	}
+
+	for _, tt := range tests {
+		err := tt.entry.Validate(labels)
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected valid entry, got %v", tt.name, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidEntry) {
+			t.Errorf("%s: expected ErrInvalidEntry, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestStrictEntries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelSubject},
+		StrictEntries: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	called := 0
+	logger.SetLogCallback(func(entry *LogEntry) error {
+		called++
+		return nil
+	})
+
+	err := logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now()})
+	if !errors.Is(err, ErrInvalidEntry) {
+		t.Errorf("expected ErrInvalidEntry, got %v", err)
+	}
+	err = logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Subject: "alice", StartTime: time.Now()})
+	if err != nil {
+		t.Errorf("expected valid entry to be recorded, got %v", err)
+	}
+
+	if value := testutil.ToFloat64(logger.GetInvalidEntries()); value != 1 {
+		t.Errorf("expected 1 invalid entry, got %v", value)
+	}
+	if count := testutil.CollectAndCount(logger.GetEnforceTotal()); count != 1 {
+		t.Errorf("expected only the valid entry to be recorded, got %d series", count)
+	}
+	if called != 1 {
+		t.Errorf("expected the callback to run for the valid entry only, got %d calls", called)
+	}
+}
+
+func TestPolicySuccessLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		PolicySuccessLabelName: "status",
+		PolicySuccessValue:     "ok",
+		PolicyFailureValue:     "error",
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, entry := range []*LogEntry{
+		{IsActive: true, EventType: EventAddPolicy},
+		{IsActive: true, EventType: EventAddPolicy, Error: errors.New("adapter error")},
+		{IsActive: true, EventType: EventAddPolicy},
+	} {
+		entry.StartTime = time.Now()
+		logger.OnAfterEvent(entry)
+	}
+
+	expected := `
+# HELP casbin_policy_operations_total Total number of policy operations
+# TYPE casbin_policy_operations_total counter
+casbin_policy_operations_total{operation="addPolicy",status="error"} 1
+casbin_policy_operations_total{operation="addPolicy",status="ok"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_policy_operations_total"); err != nil {
+		t.Error(err)
+	}
+	if rate := logger.PolicyOpSuccessRate(EventAddPolicy); math.Abs(rate-2.0/3) > 1e-9 {
+		t.Errorf("expected success rate 2/3 with custom labels, got %v", rate)
+	}
+}
+
+func TestEnforceRetries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{EnforceRetries: true})
+	defer logger.UnregisterFrom(registry)
+
+	for _, entry := range []*LogEntry{
+		{Domain: "domain1", Retries: 2},
+		{Domain: "domain1", Retries: 5},
+		{Domain: "domain1"},
+		{Domain: "domain2", Retries: 1},
+	} {
+		entry.IsActive = true
+		entry.EventType = EventEnforce
+		entry.StartTime = time.Now()
+		logger.OnAfterEvent(entry)
+	}
+
+	if value := testutil.ToFloat64(logger.GetEnforceRetries().WithLabelValues("domain1")); value != 7 {
+		t.Errorf("expected 7 retries for domain1, got %v", value)
+	}
+	if value := testutil.ToFloat64(logger.GetEnforceRetries().WithLabelValues("domain2")); value != 1 {
+		t.Errorf("expected 1 retry for domain2, got %v", value)
+	}
+	if value := testutil.ToFloat64(logger.GetEnforceMaxRetries()); value != 5 {
+		t.Errorf("expected max retries 5, got %v", value)
+	}
+}
+
+func TestReconfigure(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	var called int
+	logger.SetLogCallback(func(entry *LogEntry) error {
+		called++
+		return nil
+	})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", Allowed: true, StartTime: time.Now()})
+
+	err := logger.Reconfigure(&PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelSubject},
+		Namespace:     "myapp",
+	})
+	if err != nil {
+		t.Fatalf("Reconfigure returned error: %v", err)
+	}
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Subject: "alice", Allowed: true, StartTime: time.Now()})
+
+	expected := `
+# HELP myapp_enforce_total Total number of enforce requests
+# TYPE myapp_enforce_total counter
+myapp_enforce_total{allowed="true",subject="alice"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "myapp_enforce_total", "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+	if names := logger.MetricNames(); slices.Contains(names, "casbin_enforce_total") {
+		t.Errorf("Expected the old metrics to be unregistered, got %v", names)
+	}
+	if called != 2 {
+		t.Errorf("Expected the callback to be kept, called %d times", called)
+	}
+}
+
+func TestReconfigure_RollsBack(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	// A foreign collector owns one of the reconfigured metrics.
+	registry.MustRegister(prometheus.NewGauge(prometheus.GaugeOpts{Name: "myapp_enforce_total", Help: "Foreign"}))
+
+	if err := logger.Reconfigure(&PrometheusLoggerOptions{Namespace: "myapp"}); err == nil {
+		t.Fatal("Expected an error for a conflicting metric")
+	}
+	// A registry keeps the label names of a metric name even after it is
+	// unregistered.
+	if err := logger.Reconfigure(&PrometheusLoggerOptions{EnforceLabels: []string{LabelAllowed}}); err == nil {
+		t.Fatal("Expected an error for changed labels under the same name")
+	}
+	if err := logger.Reconfigure(&PrometheusLoggerOptions{MaxSeries: -1}); err == nil {
+		t.Fatal("Expected an error for invalid options")
+	}
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", Allowed: true, StartTime: time.Now()})
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",domain="domain1"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+	for _, name := range logger.MetricNames() {
+		if strings.HasPrefix(name, "myapp_") && name != "myapp_enforce_total" {
+			t.Errorf("Expected the partially registered metric %s to be unregistered", name)
+		}
+	}
+}
+
+func TestReconfigure_Concurrent(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{EnforceDomainConcurrency: true})
+	defer logger.UnregisterFrom(registry)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				entry := &LogEntry{EventType: EventEnforce, Domain: "domain1"}
+				logger.OnBeforeEvent(entry)
+				logger.OnAfterEvent(entry)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		other := NewPrometheusLoggerWithRegistry(prometheus.NewRegistry())
+		for j := 0; j < 100; j++ {
+			logger.SingleShotEnforce("alice", "data1", "read", "domain1", true)
+			logger.AddEnforceCounts("domain1", 2, 1)
+			logger.UpdatePolicyState("p", j)
+			logger.LogError(errors.New("watcher: lost connection"))
+			logger.Merge(other)
+			logger.Reset()
+		}
+	}()
+	for _, options := range []*PrometheusLoggerOptions{
+		{Namespace: "a", EnforceLabels: []string{LabelAllowed}},
+		{Namespace: "b", EnforceDomainConcurrency: true, EnforceLabels: []string{LabelDomain}},
+		nil,
+	} {
+		if err := logger.Reconfigure(options); err != nil {
+			t.Errorf("Reconfigure returned error: %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestReconfigure_FactoryLogger(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithFactory(promauto.With(registry))
+	defer logger.UnregisterFrom(registry)
+
+	if err := logger.Reconfigure(&PrometheusLoggerOptions{Namespace: "x"}); !errors.Is(err, ErrFactoryRegistry) {
+		t.Fatalf("Expected ErrFactoryRegistry, got %v", err)
+	}
+
+	// The current metrics keep recording into the factory's registry.
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Allowed: true, StartTime: time.Now()})
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",domain="default"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+	if count, err := testutil.GatherAndCount(registry, "x_enforce_total"); err != nil || count != 0 {
+		t.Errorf("Expected no reconfigured metrics in the registry, got %d (%v)", count, err)
+	}
+}
+
+func TestAddMirrorRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	mirror := prometheus.NewRegistry()
+	if err := logger.AddMirrorRegistry(mirror); err != nil {
+		t.Fatalf("AddMirrorRegistry returned %v", err)
+	}
+	defer logger.UnregisterFrom(mirror)
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", Allowed: true, StartTime: time.Now()})
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",domain="domain1"} 1
+`
+	for name, gatherer := range map[string]prometheus.Gatherer{"primary": registry, "mirror": mirror} {
+		if err := testutil.GatherAndCompare(gatherer, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestAddMirrorRegistry_Conflict(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	// A foreign collector owns one of the metrics on the mirror.
+	mirror := prometheus.NewRegistry()
+	conflict := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "casbin_unknown_events_total",
+		Help: "Total number of active events with an unrecognized event type",
+	}, []string{"event_type"})
+	mirror.MustRegister(conflict)
+
+	if err := logger.AddMirrorRegistry(mirror); err == nil {
+		t.Fatal("expected an error for conflicting metrics")
+	}
+
+	// The metrics registered before the conflict were rolled back, so the
+	// mirror can be added once the conflict is gone.
+	mirror.Unregister(conflict)
+	if err := logger.AddMirrorRegistry(mirror); err != nil {
+		t.Errorf("expected AddMirrorRegistry to succeed after the rollback, got %v", err)
+	}
+	logger.UnregisterFrom(mirror)
+}
+
+func TestEnforceDurationTotal(t *testing.T) {
+	for name, options := range map[string]*PrometheusLoggerOptions{
+		"default labels": {EnforceDurationTotal: true},
+		"custom labels":  {EnforceDurationTotal: true, EnforceLabels: []string{LabelDomain, LabelAllowed}},
+	} {
+		registry := prometheus.NewRegistry()
+		logger := NewPrometheusLoggerWithOptions(registry, options)
+
+		now := time.Now()
+		logger.now = func() time.Time { return now }
+		for _, duration := range []time.Duration{100 * time.Millisecond, 250 * time.Millisecond, 150 * time.Millisecond} {
+			logger.OnAfterEvent(&LogEntry{
+				IsActive:  true,
+				EventType: EventEnforce,
+				Domain:    "domain1",
+				Allowed:   true,
+				StartTime: now.Add(-duration),
+			})
+		}
+
+		if count := testutil.CollectAndCount(logger.GetEnforceDurationTotal()); count != 1 {
+			t.Errorf("%s: expected 1 series, got %d", name, count)
+		}
+		if value := testutil.ToFloat64(logger.GetEnforceDurationTotal()); math.Abs(value-0.5) > 1e-9 {
+			t.Errorf("%s: expected 0.5 seconds in total, got %v", name, value)
+		}
+		if value := testutil.ToFloat64(logger.GetEnforceTotal()); value != 3 {
+			t.Errorf("%s: expected 3 enforce requests, got %v", name, value)
+		}
+		logger.UnregisterFrom(registry)
+	}
+}
+
+func TestLogError(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.LogError(errors.New("connection refused"), "adapter: failed to load policy")
+	logger.LogError(errors.New("connection refused"), "Adapter: failed to save policy")
+	logger.LogError(errors.New("watcher: update callback failed"))
+	logger.LogError(errors.New("unexpected"), "something went wrong")
+
+	expected := `
+# HELP casbin_internal_errors_total Total number of internal casbin errors reported through LogError by category
+# TYPE casbin_internal_errors_total counter
+casbin_internal_errors_total{category="adapter"} 2
+casbin_internal_errors_total{category="other"} 1
+casbin_internal_errors_total{category="watcher"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_internal_errors_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEnforceWaitEval(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{EnforceWaitEval: true})
+	defer logger.UnregisterFrom(registry)
+
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time { return start.Add(500 * time.Millisecond) }
+
+	// Queued for 300ms, then evaluated for 200ms.
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		Domain:    "domain1",
+		StartTime: start,
+		EvalStart: start.Add(300 * time.Millisecond),
+	})
+	// WaitStart takes precedence over StartTime.
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		Domain:    "domain1",
+		StartTime: start.Add(400 * time.Millisecond),
+		WaitStart: start,
+		EvalStart: start.Add(100 * time.Millisecond),
+	})
+	// Without EvalStart only the total duration is recorded.
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", StartTime: start})
+
+	families, err := gatherCollectors(logger.GetEnforceWaitDuration(), logger.GetEnforceEvalDuration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sums := make(map[string]float64)
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		histogram := family.GetMetric()[0].GetHistogram()
+		sums[family.GetName()] = histogram.GetSampleSum()
+		counts[family.GetName()] = histogram.GetSampleCount()
+	}
+
+	if counts["casbin_enforce_wait_duration_seconds"] != 2 || math.Abs(sums["casbin_enforce_wait_duration_seconds"]-0.4) > 1e-9 {
+		t.Errorf("expected 2 wait observations summing to 0.4s, got %d summing to %v",
+			counts["casbin_enforce_wait_duration_seconds"], sums["casbin_enforce_wait_duration_seconds"])
+	}
+	if counts["casbin_enforce_eval_duration_seconds"] != 2 || math.Abs(sums["casbin_enforce_eval_duration_seconds"]-0.6) > 1e-9 {
+		t.Errorf("expected 2 eval observations summing to 0.6s, got %d summing to %v",
+			counts["casbin_enforce_eval_duration_seconds"], sums["casbin_enforce_eval_duration_seconds"])
+	}
+	if value := testutil.ToFloat64(logger.GetEnforceTotal()); value != 3 {
+		t.Errorf("expected 3 enforce requests, got %v", value)
+	}
+}
+
+func TestEnforceCPU(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{EnforceCPU: true})
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", StartTime: time.Now(), CPUDuration: 30 * time.Millisecond})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", StartTime: time.Now(), CPUDuration: 20 * time.Millisecond})
+	// Without CPUDuration nothing is observed.
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", StartTime: time.Now()})
+
+	families, err := gatherCollectors(logger.GetEnforceCPUDuration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	histogram := families[0].GetMetric()[0].GetHistogram()
+	if histogram.GetSampleCount() != 2 || math.Abs(histogram.GetSampleSum()-0.05) > 1e-9 {
+		t.Errorf("Expected 2 observations summing to 0.05s, got %d summing to %v", histogram.GetSampleCount(), histogram.GetSampleSum())
+	}
+}
+
+func TestDenyTypeLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelDenyType},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, entry := range []*LogEntry{
+		{Allowed: true},
+		{Allowed: false},
+		{Allowed: false},
+		{Allowed: false, ExplicitDeny: true},
+	} {
+		entry.IsActive = true
+		entry.EventType = EventEnforce
+		entry.StartTime = time.Now()
+		logger.OnAfterEvent(entry)
+	}
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="false",deny_type="default_deny"} 2
+casbin_enforce_total{allowed="false",deny_type="explicit_deny"} 1
+casbin_enforce_total{allowed="true",deny_type="none"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEnforceDenyCause(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{EnforceDenyCause: true})
+	defer logger.UnregisterFrom(registry)
+
+	for _, entry := range []*LogEntry{
+		{Allowed: true},
+		{DenyCause: DenyCauseNoRole},
+		{DenyCause: DenyCauseNoRole},
+		{DenyCause: DenyCauseRoleDenied},
+		{DenyCause: DenyCauseNoPolicy, Domain: "domain1"},
+		{},
+		{DenyCause: "typo"},
+	} {
+		entry.IsActive = true
+		entry.EventType = EventEnforce
+		entry.StartTime = time.Now()
+		logger.OnAfterEvent(entry)
+	}
+
+	expected := `
+# HELP casbin_enforce_denies_total Total number of denied enforce requests by cause
+# TYPE casbin_enforce_denies_total counter
+casbin_enforce_denies_total{deny_cause="no_policy",domain="domain1"} 1
+casbin_enforce_denies_total{deny_cause="no_role",domain="default"} 2
+casbin_enforce_denies_total{deny_cause="role_denied",domain="default"} 1
+casbin_enforce_denies_total{deny_cause="unknown",domain="default"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_denies_total"); err != nil {
+		t.Error(err)
+	}
+
+	if logger := NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), nil); logger.GetEnforceDenies() != nil {
+		t.Error("Expected no enforce denies metric by default")
+	}
+}
+
+func TestEnforceCache(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{EnforceCache: true})
+	defer logger.UnregisterFrom(registry)
+
+	hit, miss := true, false
+	for _, entry := range []*LogEntry{
+		{Domain: "domain1", CacheHit: &hit},
+		{Domain: "domain1", CacheHit: &hit},
+		{Domain: "domain1", CacheHit: &miss},
+		{Domain: "domain2", CacheHit: &miss},
+		// Without a cache, neither metric is recorded.
+		{Domain: "domain3"},
+	} {
+		entry.IsActive = true
+		entry.EventType = EventEnforce
+		entry.StartTime = time.Now()
+		logger.OnAfterEvent(entry)
+	}
+
+	expected := `
+# HELP casbin_enforce_cache_hits_total Total number of enforce requests served from the decision cache
+# TYPE casbin_enforce_cache_hits_total counter
+casbin_enforce_cache_hits_total{domain="domain1"} 2
+# HELP casbin_enforce_cache_misses_total Total number of enforce requests not found in the decision cache
+# TYPE casbin_enforce_cache_misses_total counter
+casbin_enforce_cache_misses_total{domain="domain1"} 1
+casbin_enforce_cache_misses_total{domain="domain2"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_cache_hits_total", "casbin_enforce_cache_misses_total"); err != nil {
+		t.Error(err)
+	}
+
+	if logger := NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), nil); logger.GetEnforceCacheHits() != nil || logger.GetEnforceCacheMisses() != nil {
+		t.Error("Expected no enforce cache metrics by default")
+	}
+}
+
+func TestDenyCauseOf(t *testing.T) {
+	for _, tc := range []struct {
+		explain  []string
+		hasRole  bool
+		expected string
+	}{
+		{[]string{"alice", "data1", "read", "deny"}, true, DenyCauseRoleDenied},
+		{[]string{"admin", "data1", "write", "deny"}, false, DenyCauseRoleDenied},
+		{nil, false, DenyCauseNoRole},
+		{nil, true, DenyCauseNoPolicy},
+	} {
+		if got := DenyCauseOf(tc.explain, tc.hasRole); got != tc.expected {
+			t.Errorf("DenyCauseOf(%v, %v) = %q, expected %q", tc.explain, tc.hasRole, got, tc.expected)
+		}
+	}
+}
+
+func TestHealthy(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time { return now }
+
+	if logger.Healthy(time.Minute) {
+		t.Error("expected unhealthy before any enforce request")
+	}
+	if !logger.Healthy(0) {
+		t.Error("expected healthy without a staleness bound")
+	}
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: now})
+	now = now.Add(30 * time.Second)
+	if !logger.Healthy(time.Minute) {
+		t.Error("expected healthy within the staleness window")
+	}
+
+	now = now.Add(time.Minute)
+	if logger.Healthy(time.Minute) {
+		t.Error("expected unhealthy after the staleness window")
+	}
+
+	logger.SingleShotEnforce("alice", "data1", "read", "", true)
+	if !logger.Healthy(time.Minute) {
+		t.Error("expected SingleShotEnforce to refresh the last enforce time")
+	}
+}
+
+func TestCountOnlyDuration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{CountOnlyDuration: true})
+	defer logger.UnregisterFrom(registry)
+
+	now := time.Now()
+	logger.now = func() time.Time { return now }
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Allowed: true, StartTime: now.Add(-250 * time.Millisecond)})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Allowed: true, StartTime: now.Add(-500 * time.Millisecond)})
+
+	expected := `
+# HELP casbin_enforce_duration_seconds Duration of enforce requests in seconds
+# TYPE casbin_enforce_duration_seconds histogram
+casbin_enforce_duration_seconds_bucket{allowed="true",domain="default",le="+Inf"} 2
+casbin_enforce_duration_seconds_sum{allowed="true",domain="default"} 0.75
+casbin_enforce_duration_seconds_count{allowed="true",domain="default"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_duration_seconds"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestEnforceLabelOrder(t *testing.T) {
+	orders := [][]string{
+		{LabelAction, LabelSubject, LabelAllowed, LabelDomain},
+		{LabelSubject, LabelAction, LabelDomain, LabelAllowed},
+		{LabelAllowed, LabelDomain, LabelSubject, LabelAction},
+		{LabelDomain, LabelAction, LabelAllowed, LabelSubject},
+	}
+	expected := `Desc{fqName: "casbin_enforce_total", help: "Total number of enforce requests", constLabels: {}, variableLabels: {allowed,domain,subject,action}}`
+
+	for _, labels := range orders {
+		registry := prometheus.NewRegistry()
+		logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{EnforceLabels: labels})
+
+		if !slices.Equal(logger.enforceLabels, []string{LabelAllowed, LabelDomain, LabelSubject, LabelAction}) {
+			t.Errorf("labels %v: expected canonical order, got %v", labels, logger.enforceLabels)
+		}
+		descs := make(chan *prometheus.Desc, 1)
+		logger.GetEnforceTotal().Describe(descs)
+		if desc := (<-descs).String(); desc != expected {
+			t.Errorf("labels %v: expected %s, got %s", labels, expected, desc)
+		}
+		logger.UnregisterFrom(registry)
+	}
+
+	// Reordered default labels use the default labels fast path.
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelDomain, LabelAllowed},
+	})
+	defer logger.UnregisterFrom(registry)
+	if !logger.defaultLabelsFastPath {
+		t.Error("expected reordered default labels to use the fast path")
+	}
+}
+
+func TestLogPolicy(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.LogPolicy(map[string][][]string{
+		"p": {
+			{"alice", "data1", "read"},
+			{"bob", "data2", "write"},
+			{"data2_admin", "data2", "read"},
+		},
+		"g": {
+			{"alice", "data2_admin"},
+		},
+		"g2": {
+			{"data1", "data_group"},
+		},
+	})
+	logger.LogPolicy(map[string][][]string{
+		"p": {
+			{"alice", "data1", "read"},
+			{"bob", "data2", "write"},
+		},
+		"g": {
+			{"alice", "data2_admin"},
+			{"bob", "data2_admin"},
+		},
+	})
+
+	expected := `
+# HELP casbin_policy_state_count Current number of policy rules by ptype
+# TYPE casbin_policy_state_count gauge
+casbin_policy_state_count{ptype="g"} 2
+casbin_policy_state_count{ptype="p"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_policy_state_count"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckRegistry(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	conflict := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "casbin_enforce_total",
+		Help: "Another library's enforce counter",
+	}, []string{"result"})
+	registry.MustRegister(conflict)
+	conflict.WithLabelValues("ok").Inc()
+
+	other := prometheus.NewGauge(prometheus.GaugeOpts{Name: "unrelated", Help: "Unrelated gauge"})
+	registry.MustRegister(other)
+
+	collisions := CheckRegistry(registry, nil)
+	if !slices.Equal(collisions, []string{"casbin_enforce_total"}) {
+		t.Errorf("expected casbin_enforce_total to be reported, got %v", collisions)
+	}
+
+	// Strict naming is applied before comparing.
+	strict := prometheus.NewGauge(prometheus.GaugeOpts{Name: "casbin_policy_rules", Help: "Rules"})
+	registry.MustRegister(strict)
+	collisions = CheckRegistry(registry, &PrometheusLoggerOptions{StrictNaming: true})
+	if !slices.Equal(collisions, []string{"casbin_enforce_total", "casbin_policy_rules"}) {
+		t.Errorf("expected strict names to be reported, got %v", collisions)
+	}
+
+	if collisions := CheckRegistry(prometheus.NewRegistry(), nil); len(collisions) != 0 {
+		t.Errorf("expected no collisions on an empty registry, got %v", collisions)
+	}
+}
+
+func TestPolicyReloadInterval(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{PolicyReloadInterval: true})
+	defer logger.UnregisterFrom(registry)
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time { return now }
+	load := func() {
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventLoadPolicy, StartTime: now})
+	}
+
+	histogram := func() *dto.Histogram {
+		families, err := gatherCollectors(logger.GetPolicyReloadInterval())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return families[0].GetMetric()[0].GetHistogram()
+	}
+
+	load()
+	if count := histogram().GetSampleCount(); count != 0 {
+		t.Errorf("Expected the first load to record nothing, got %d samples", count)
+	}
+
+	now = now.Add(90 * time.Second)
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventAddPolicy, StartTime: now})
+	load()
+
+	if histogram := histogram(); histogram.GetSampleCount() != 1 || histogram.GetSampleSum() != 90 {
+		t.Errorf("Expected one interval of 90s, got %d summing to %v", histogram.GetSampleCount(), histogram.GetSampleSum())
+	}
+}
+
+func TestPolicyOperationErrors(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{PolicyOperationErrors: true})
+	defer logger.UnregisterFrom(registry)
+
+	saveErr := errors.New("save failed")
+	for _, entry := range []*LogEntry{
+		{IsActive: true, EventType: EventSavePolicy, Error: saveErr, ErrorCategory: "db_down"},
+		{IsActive: true, EventType: EventSavePolicy, Error: saveErr, ErrorCategory: "db_down"},
+		{IsActive: true, EventType: EventSavePolicy, Error: saveErr, ErrorCategory: "conflict"},
+		{IsActive: true, EventType: EventAddPolicy, Error: saveErr},
+		// Successful operations are not counted, whatever their category.
+		{IsActive: true, EventType: EventSavePolicy, ErrorCategory: "conflict"},
+	} {
+		logger.OnAfterEvent(entry)
+	}
+
+	errorsTotal := logger.GetPolicyOperationErrors()
+	for _, tc := range []struct {
+		operation, category string
+		expected            float64
+	}{
+		{"savePolicy", "db_down", 2},
+		{"savePolicy", "conflict", 1},
+		{"addPolicy", "unknown", 1},
+	} {
+		if got := testutil.ToFloat64(errorsTotal.WithLabelValues(tc.operation, tc.category)); got != tc.expected {
+			t.Errorf("Expected %v %s errors in category %s, got %v", tc.expected, tc.operation, tc.category, got)
+		}
+	}
+	if count := testutil.CollectAndCount(errorsTotal); count != 3 {
+		t.Errorf("Expected 3 error series, got %d", count)
+	}
+
+	if logger := NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), nil); logger.GetPolicyOperationErrors() != nil {
+		t.Error("Expected no policy operation errors metric by default")
+	}
+}
+
+func TestEventsFiltered(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.SetEventTypes([]EventType{EventEnforce})
+	for _, eventType := range []EventType{EventEnforce, EventLoadPolicy, EventAddPolicy, EventLoadPolicy, EventEnforce} {
+		logger.OnBeforeEvent(&LogEntry{EventType: eventType})
+	}
+
+	expected := `
+# HELP casbin_events_filtered_total Total number of events not logged because their event type is disabled
+# TYPE casbin_events_filtered_total counter
+casbin_events_filtered_total{event_type="addPolicy"} 1
+casbin_events_filtered_total{event_type="loadPolicy"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_events_filtered_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewPrometheusLoggerWithFactory(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithFactory(promauto.With(registry))
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Allowed: true, StartTime: time.Now()})
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",domain="default"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+	if err := testutil.GatherAndCompare(logger.gatherer, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Errorf("Expected the logger gatherer to expose its metrics: %v", err)
+	}
+
+	if _, err := logger.registerer(); !errors.Is(err, ErrFactoryRegistry) {
+		t.Errorf("Expected ErrFactoryRegistry for the registerer of a factory logger, got %v", err)
+	}
+
+	if !logger.UnregisterFrom(registry) {
+		t.Error("Expected every metric to be unregistered from the factory registry")
+	}
+	if count, err := testutil.GatherAndCount(registry); err != nil || count != 0 {
+		t.Errorf("Expected no metrics left in the registry, got %d (%v)", count, err)
+	}
+}
+
+func TestStrictMode(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelOutcome},
+		StrictMode:    true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	contradictory := &LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		Allowed:   true,
+		Error:     errors.New("matcher error"),
+		StartTime: time.Now(),
+	}
+	logger.OnAfterEvent(contradictory)
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Allowed: true, StartTime: time.Now()})
+
+	if value := testutil.ToFloat64(logger.GetEntryInconsistencies()); value != 1 {
+		t.Errorf("Expected 1 inconsistency, got %v", value)
+	}
+	if !contradictory.Allowed {
+		t.Error("Expected the entry itself to be left unchanged")
+	}
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="false",outcome="error"} 1
+casbin_enforce_total{allowed="true",outcome="allow"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAttributeLabels(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		AttributeLabels: []string{"department", "clearance"},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, attributes := range []map[string]string{
+		{"department": "engineering", "clearance": "secret", "location": "berlin"},
+		{"department": "engineering", "clearance": "secret"},
+		{"department": "finance"},
+		nil,
+	} {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:   true,
+			EventType:  EventEnforce,
+			Allowed:    true,
+			Attributes: attributes,
+			StartTime:  time.Now(),
+		})
+	}
+
+	expected := `
+# HELP casbin_enforce_total Total number of enforce requests
+# TYPE casbin_enforce_total counter
+casbin_enforce_total{allowed="true",clearance="none",department="finance",domain="default"} 1
+casbin_enforce_total{allowed="true",clearance="none",department="none",domain="default"} 1
+casbin_enforce_total{allowed="true",clearance="secret",department="engineering",domain="default"} 2
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "casbin_enforce_total"); err != nil {
+		t.Error(err)
+	}
+
+	for _, labels := range [][]string{{"domain"}, {"dept-name"}, {"department", "department"}} {
+		if _, err := newPrometheusLogger(&PrometheusLoggerOptions{AttributeLabels: labels}); err == nil {
+			t.Errorf("Expected an error for attribute labels %v", labels)
+		}
+	}
+}
+
+func TestEnforceConsecutiveDenies(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceConsecutiveDenies: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for i, step := range []struct {
+		allowed  bool
+		expected float64
+	}{
+		{false, 1},
+		{false, 2},
+		{true, 0},
+		{false, 1},
+	} {
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "tenant1", Allowed: step.allowed, StartTime: time.Now()})
+		if value := testutil.ToFloat64(logger.GetEnforceConsecutiveDenies().WithLabelValues("tenant1")); value != step.expected {
+			t.Errorf("Step %d: expected a streak of %v, got %v", i, step.expected, value)
+		}
+	}
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "tenant2", Allowed: true, StartTime: time.Now()})
+	if value := testutil.ToFloat64(logger.GetEnforceConsecutiveDenies().WithLabelValues("tenant1")); value != 1 {
+		t.Errorf("Expected another domain to leave the streak at 1, got %v", value)
+	}
+}
+
+func TestEnforceLatencyEWMA(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLatencyEWMAAlpha: 0.5,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	now := time.Now()
+	logger.now = func() time.Time { return now }
+	record := func(d time.Duration) float64 {
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", StartTime: now.Add(-d)})
+		return testutil.ToFloat64(logger.GetEnforceLatencyEWMA().WithLabelValues("domain1"))
+	}
+
+	// The first request sets the average, later ones move it halfway.
+	for i, step := range []struct {
+		duration time.Duration
+		expected float64
+	}{
+		{100 * time.Millisecond, 0.1},
+		{200 * time.Millisecond, 0.15},
+		{400 * time.Millisecond, 0.275},
+	} {
+		if value := record(step.duration); math.Abs(value-step.expected) > 1e-9 {
+			t.Errorf("Step %d: expected an average of %v, got %v", i, step.expected, value)
+		}
+	}
+
+	// A steady latency pulls the average towards it.
+	var value float64
+	for i := 0; i < 30; i++ {
+		value = record(50 * time.Millisecond)
+	}
+	if math.Abs(value-0.05) > 1e-6 {
+		t.Errorf("Expected the average to converge to 0.05, got %v", value)
+	}
+}
+
+func TestRecordModelLoad(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	// Nothing is exported before the first model load.
+	if count, err := testutil.GatherAndCount(registry, "casbin_model_load_duration_seconds", "casbin_model_load_total"); err != nil || count != 0 {
+		t.Errorf("Expected no model load series before a load, got %d (%v)", count, err)
+	}
+
+	logger.RecordModelLoad(30*time.Millisecond, nil)
+	logger.RecordModelLoad(20*time.Millisecond, errors.New("syntax error in matcher"))
+
+	if value := testutil.ToFloat64(logger.GetModelLoadTotal().WithLabelValues("true")); value != 1 {
+		t.Errorf("Expected 1 successful model load, got %v", value)
+	}
+	if value := testutil.ToFloat64(logger.GetModelLoadTotal().WithLabelValues("false")); value != 1 {
+		t.Errorf("Expected 1 failed model load, got %v", value)
+	}
+
+	families, err := gatherCollectors(logger.GetModelLoadDuration())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var count uint64
+	var sum float64
+	for _, metric := range families[0].GetMetric() {
+		count += metric.GetHistogram().GetSampleCount()
+		sum += metric.GetHistogram().GetSampleSum()
+	}
+	if count != 2 || math.Abs(sum-0.05) > 1e-9 {
+		t.Errorf("Expected 2 observations summing to 0.05s, got %d summing to %v", count, sum)
+	}
+}
+
+func TestExpectedArgCount(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	var mismatched []int
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		ExpectedArgCount: 3,
+		OnArgMismatch: func(entry *LogEntry) {
+			mismatched = append(mismatched, entry.ArgCount)
+		},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, argCount := range []int{3, 2, 0, 4, 3} {
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), ArgCount: argCount})
+	}
+
+	if value := testutil.ToFloat64(logger.GetEnforceArgMismatch()); value != 2 {
+		t.Errorf("Expected 2 mismatches, got %v", value)
+	}
+	if !slices.Equal(mismatched, []int{2, 4}) {
+		t.Errorf("Expected the hook for arg counts 2 and 4, got %v", mismatched)
+	}
+}
+
+func TestEnforceDomainConcurrency(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceDomainConcurrency: true,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	// Start 4 requests for tenant1 and 2 for tenant2 concurrently.
+	var entries []*LogEntry
+	for _, domain := range []string{"tenant1", "tenant1", "tenant1", "tenant1", "tenant2", "tenant2"} {
+		entries = append(entries, &LogEntry{EventType: EventEnforce, Domain: domain})
+	}
+	var wg sync.WaitGroup
+	for _, entry := range entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.OnBeforeEvent(entry)
+		}()
+	}
+	wg.Wait()
+
+	gauges := func(domain string) (float64, float64) {
+		return testutil.ToFloat64(logger.GetEnforceDomainConcurrency().WithLabelValues(domain)),
+			testutil.ToFloat64(logger.GetEnforceDomainConcurrencyMax().WithLabelValues(domain))
+	}
+	if current, highest := gauges("tenant1"); current != 4 || highest != 4 {
+		t.Errorf("Expected 4 in-flight requests for tenant1 with a maximum of 4, got %v and %v", current, highest)
+	}
+	if current, highest := gauges("tenant2"); current != 2 || highest != 2 {
+		t.Errorf("Expected 2 in-flight requests for tenant2 with a maximum of 2, got %v and %v", current, highest)
+	}
+
+	// Finish three tenant1 requests and both tenant2 requests.
+	for _, entry := range slices.Concat(entries[:3], entries[4:]) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.OnAfterEvent(entry)
+		}()
+	}
+	wg.Wait()
+
+	if current, highest := gauges("tenant1"); current != 1 || highest != 4 {
+		t.Errorf("Expected 1 in-flight request for tenant1 with a maximum of 4, got %v and %v", current, highest)
+	}
+	if current, highest := gauges("tenant2"); current != 0 || highest != 2 {
+		t.Errorf("Expected 0 in-flight requests for tenant2 with a maximum of 2, got %v and %v", current, highest)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// overflowLabelValue replaces label values of enforce series beyond MaxSeries.
+const overflowLabelValue = "__overflow__"
+
+// seriesTracker tracks the active enforce label combinations in least
+// recently used order.
+type seriesTracker struct {
+	mu    sync.Mutex
+	max   int
+	order *list.List // of *trackedSeries, most recently used first
+	items map[string]*list.Element
+	// size is set to the number of tracked label combinations if not nil.
+	size prometheus.Gauge
+}
+
+// trackedSeries is a label combination known to the tracker.
+type trackedSeries struct {
+	key       string
+	values    []string
+	lastTouch time.Time
+}
+
+func newSeriesTracker(max int, size prometheus.Gauge) *seriesTracker {
+	return &seriesTracker{
+		max:   max,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+		size:  size,
+	}
+}
+
+// updateSize sets the size gauge to the number of tracked label
+// combinations. The caller must hold t.mu.
+func (t *seriesTracker) updateSize() {
+	if t.size != nil {
+		t.size.Set(float64(t.order.Len()))
+	}
+}
+
+// touch marks the label combination as used at now. It returns false if the
+// combination is new and the tracker is already full.
+func (t *seriesTracker) touch(values []string, now time.Time) bool {
+	key := strings.Join(values, "\xff")
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if element, ok := t.items[key]; ok {
+		element.Value.(*trackedSeries).lastTouch = now
+		t.order.MoveToFront(element)
+		return true
+	}
+	if t.max > 0 && t.order.Len() >= t.max {
+		return false
+	}
+
+	series := &trackedSeries{key: key, values: append([]string(nil), values...), lastTouch: now}
+	t.items[key] = t.order.PushFront(series)
+	t.updateSize()
+	return true
+}
+
+// reset forgets every tracked label combination.
+func (t *seriesTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.order.Init()
+	t.items = make(map[string]*list.Element)
+	t.updateSize()
+}
+
+// expire removes and returns the label combinations last used before cutoff.
+func (t *seriesTracker) expire(cutoff time.Time) [][]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var expired [][]string
+	for element := t.order.Back(); element != nil; element = t.order.Back() {
+		series := element.Value.(*trackedSeries)
+		if !series.lastTouch.Before(cutoff) {
+			break
+		}
+		t.order.Remove(element)
+		delete(t.items, series.key)
+		expired = append(expired, series.values)
+	}
+	t.updateSize()
+	return expired
+}
+
+// overflowValues returns values with every label except the bounded
+// "allowed", "outcome", "deny_type" and "event_type" labels replaced by the
+// overflow marker.
+func overflowValues(labels, values []string) []string {
+	overflow := make([]string, len(values))
+	for i, label := range labels {
+		if label == LabelAllowed || label == LabelOutcome || label == LabelDenyType || label == LabelEventType {
+			overflow[i] = values[i]
+		} else {
+			overflow[i] = overflowLabelValue
+		}
+	}
+	return overflow
+}
+
+// runIdleSweeper removes idle enforce series every ttl until ctx is done.
+func (p *PrometheusLogger) runIdleSweeper(ctx context.Context, ttl time.Duration) {
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.sweepIdleSeries(ttl)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sweepIdleSeries deletes the enforce series not recorded within ttl.
+func (p *PrometheusLogger) sweepIdleSeries(ttl time.Duration) {
+	for _, values := range p.series.expire(p.now().Add(-ttl)) {
+		if p.enforceTotal != nil {
+			p.enforceTotal.DeleteLabelValues(values...)
+		}
+		if p.enforceDuration != nil {
+			p.enforceDuration.DeleteLabelValues(values...)
+		}
+		if p.enforceDurationTotal != nil {
+			p.enforceDurationTotal.DeleteLabelValues(values...)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMaxSeries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelDomain, LabelSubject},
+		MaxSeries:     100,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for i := 0; i < 2000; i++ {
+		logger.OnAfterEvent(&LogEntry{
+			IsActive:  true,
+			EventType: EventEnforce,
+			StartTime: time.Now(),
+			Subject:   fmt.Sprintf("user%d", i),
+			Domain:    "domain1",
+			Allowed:   true,
+		})
+	}
+
+	// 100 tracked series plus the single overflow series
+	if count := testutil.CollectAndCount(logger.enforceTotal); count != 101 {
+		t.Errorf("Expected 101 enforce series, got %d", count)
+	}
+
+	if value := testutil.ToFloat64(logger.GetEnforceSeriesOverflow()); value != 1900 {
+		t.Errorf("Expected 1900 overflowed requests, got %v", value)
+	}
+
+	overflow := logger.enforceTotal.WithLabelValues("true", overflowLabelValue, overflowLabelValue)
+	if value := testutil.ToFloat64(overflow); value != 1900 {
+		t.Errorf("Expected 1900 requests in the overflow series, got %v", value)
+	}
+
+	// Known combinations keep being recorded under their own labels
+	logger.OnAfterEvent(&LogEntry{
+		IsActive:  true,
+		EventType: EventEnforce,
+		StartTime: time.Now(),
+		Subject:   "user0",
+		Domain:    "domain1",
+		Allowed:   true,
+	})
+	if value := testutil.ToFloat64(logger.enforceTotal.WithLabelValues("true", "domain1", "user0")); value != 2 {
+		t.Errorf("Expected 2 requests for a tracked series, got %v", value)
+	}
+}
+
+func TestLoggerSeries(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelDomain, LabelSubject},
+		MaxSeries:     10,
+	})
+	defer logger.UnregisterFrom(registry)
+
+	record := func(subject string) {
+		logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, StartTime: time.Now(), Subject: subject, Allowed: true})
+	}
+	for i := 0; i < 7; i++ {
+		record(fmt.Sprintf("user%d", i))
+		record(fmt.Sprintf("user%d", i))
+	}
+	if value := testutil.ToFloat64(logger.GetLoggerSeries()); value != 7 {
+		t.Errorf("Expected 7 tracked series, got %v", value)
+	}
+	if count := testutil.CollectAndCount(logger.GetEnforceTotal()); count != 7 {
+		t.Errorf("Expected the gauge to match the 7 enforce series, got %d series", count)
+	}
+
+	// Series beyond MaxSeries go to the overflow series and are not tracked.
+	for i := 7; i < 20; i++ {
+		record(fmt.Sprintf("user%d", i))
+	}
+	if value := testutil.ToFloat64(logger.GetLoggerSeries()); value != 10 {
+		t.Errorf("Expected 10 tracked series at the cap, got %v", value)
+	}
+
+	logger.ResetEnforceMetrics()
+	if value := testutil.ToFloat64(logger.GetLoggerSeries()); value != 0 {
+		t.Errorf("Expected no tracked series after a reset, got %v", value)
+	}
+
+	if logger := NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), nil); logger.GetLoggerSeries() != nil {
+		t.Error("Expected no logger series gauge without series tracking")
+	}
+}
+
+func TestSeriesTracker_LRUOrder(t *testing.T) {
+	tracker := newSeriesTracker(2, nil)
+
+	now := time.Now()
+
+	if !tracker.touch([]string{"a"}, now) || !tracker.touch([]string{"b"}, now) {
+		t.Fatal("Expected combinations below the cap to be tracked")
+	}
+	if tracker.touch([]string{"c"}, now) {
+		t.Error("Expected a new combination beyond the cap to be rejected")
+	}
+
+	tracker.touch([]string{"a"}, now)
+	front := tracker.order.Front().Value.(*trackedSeries)
+	if front.values[0] != "a" {
+		t.Errorf("Expected most recently used combination first, got %v", front.values)
+	}
+}
+
+func TestIdleSeriesTTL(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		IdleSeriesTTL: time.Hour,
+	})
+	defer logger.UnregisterFrom(registry)
+	defer logger.Close()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	logger.now = func() time.Time { return now }
+
+	record := func(domain string) {
+		entry := &LogEntry{EventType: EventEnforce, Domain: domain, Allowed: true}
+		logger.OnBeforeEvent(entry)
+		logger.OnAfterEvent(entry)
+	}
+
+	record("stale")
+	now = now.Add(45 * time.Minute)
+	record("fresh")
+
+	// Only the "stale" series has been idle longer than the TTL
+	now = now.Add(30 * time.Minute)
+	logger.sweepIdleSeries(time.Hour)
+
+	if count := testutil.CollectAndCount(logger.enforceTotal); count != 1 {
+		t.Errorf("Expected 1 enforce series after sweep, got %d", count)
+	}
+	if count := testutil.CollectAndCount(logger.enforceDuration); count != 1 {
+		t.Errorf("Expected 1 enforce duration series after sweep, got %d", count)
+	}
+	if value := testutil.ToFloat64(logger.enforceTotal.WithLabelValues("true", "fresh")); value != 1 {
+		t.Errorf("Expected the fresh series to survive, got %v", value)
+	}
+}
+
+func TestBaseContext(t *testing.T) {
+	// waitForGoroutines waits until at most max goroutines are running.
+	waitForGoroutines := func(max int) int {
+		deadline := time.Now().Add(time.Second)
+		for runtime.NumGoroutine() > max && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		return runtime.NumGoroutine()
+	}
+
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	const loggers = 10
+	for i := 0; i < loggers; i++ {
+		NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), &PrometheusLoggerOptions{
+			IdleSeriesTTL: time.Hour,
+			BaseContext:   ctx,
+		})
+	}
+	if running := runtime.NumGoroutine(); running < before+loggers {
+		t.Fatalf("Expected at least %d goroutines with the sweepers running, got %d", before+loggers, running)
+	}
+
+	cancel()
+	if running := waitForGoroutines(before); running > before {
+		t.Errorf("Expected the sweepers to stop after the base context was cancelled, %d goroutines still running, %d before", running, before)
+	}
+
+	// Without a base context, Close stops the sweeper.
+	logger := NewPrometheusLoggerWithOptions(prometheus.NewRegistry(), &PrometheusLoggerOptions{IdleSeriesTTL: time.Hour})
+	logger.Close()
+	logger.Close()
+	if running := waitForGoroutines(before); running > before {
+		t.Errorf("Expected the sweeper to stop after Close, %d goroutines still running, %d before", running, before)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+// MetricSink records the core enforce and policy operation measurements, so
+// that they can be sent to another metrics system instead of Prometheus, for
+// example to compare both. Label values are given in the order of the labels
+// of the corresponding Prometheus metric, and durations in the DurationUnit.
+// All other metrics are recorded in Prometheus only. A sink must be safe for
+// concurrent use and must not retain the label value slices.
+type MetricSink interface {
+	// IncEnforce counts an enforce request, like casbin_enforce_total, with
+	// the values of the enforce labels.
+	IncEnforce(labelValues []string)
+	// AddEnforce counts count enforce requests tallied elsewhere, like
+	// IncEnforce, for AddEnforceCounts.
+	AddEnforce(labelValues []string, count float64)
+	// ObserveEnforceDuration records the duration of an enforce request,
+	// like casbin_enforce_duration_seconds, with the values of the enforce
+	// labels.
+	ObserveEnforceDuration(labelValues []string, duration float64)
+	// IncPolicyOp counts a policy operation, like
+	// casbin_policy_operations_total, with the values of the operation and
+	// success labels, followed by the ptype, adapter and source if enabled.
+	IncPolicyOp(labelValues []string)
+	// ObservePolicyOpDuration records the duration of a policy operation,
+	// like casbin_policy_operations_duration_seconds, with the value of the
+	// operation label, followed by the adapter and source if enabled.
+	ObservePolicyOpDuration(labelValues []string, duration float64)
+}
+
+// prometheusSink is the default MetricSink, recording into the Prometheus
+// metrics of the logger.
+type prometheusSink PrometheusLogger
+
+// PrometheusSink returns the MetricSink recording into the Prometheus metrics
+// of the logger, which is used unless MetricSink is set. A custom sink can
+// forward to it to record into both.
+func (p *PrometheusLogger) PrometheusSink() MetricSink {
+	return (*prometheusSink)(p)
+}
+
+// IncEnforce implements MetricSink.
+func (s *prometheusSink) IncEnforce(labelValues []string) {
+	if s.enforceTotal != nil {
+		s.enforceTotal.WithLabelValues(labelValues...).Inc()
+	}
+}
+
+// AddEnforce implements MetricSink.
+func (s *prometheusSink) AddEnforce(labelValues []string, count float64) {
+	if s.enforceTotal != nil {
+		s.enforceTotal.WithLabelValues(labelValues...).Add(count)
+	}
+}
+
+// ObserveEnforceDuration implements MetricSink. The duration is also added
+// to casbin_enforce_duration_seconds_total if it is enabled.
+func (s *prometheusSink) ObserveEnforceDuration(labelValues []string, duration float64) {
+	if s.enforceDuration != nil {
+		s.enforceDuration.WithLabelValues(labelValues...).Observe(duration)
+	}
+	if s.enforceDurationTotal != nil {
+		s.enforceDurationTotal.WithLabelValues(labelValues...).Add(duration)
+	}
+}
+
+// IncPolicyOp implements MetricSink.
+func (s *prometheusSink) IncPolicyOp(labelValues []string) {
+	if s.policyOpsTotal != nil {
+		s.policyOpsTotal.WithLabelValues(labelValues...).Inc()
+	}
+}
+
+// ObservePolicyOpDuration implements MetricSink.
+func (s *prometheusSink) ObservePolicyOpDuration(labelValues []string, duration float64) {
+	if s.policyOpsDuration != nil {
+		s.policyOpsDuration.WithLabelValues(labelValues...).Observe(duration)
+	}
+}
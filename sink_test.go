@@ -0,0 +1,157 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// memorySink is a MetricSink keeping the recorded measurements in memory,
+// optionally forwarding them to another sink.
+type memorySink struct {
+	mu        sync.Mutex
+	counts    map[string]float64
+	durations map[string][]float64
+	next      MetricSink
+}
+
+func newMemorySink(next MetricSink) *memorySink {
+	return &memorySink{counts: make(map[string]float64), durations: make(map[string][]float64), next: next}
+}
+
+func (s *memorySink) count(metric string, labelValues []string, count float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counts[metric+"{"+strings.Join(labelValues, ",")+"}"] += count
+}
+
+func (s *memorySink) observe(metric string, labelValues []string, duration float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := metric + "{" + strings.Join(labelValues, ",") + "}"
+	s.durations[key] = append(s.durations[key], duration)
+}
+
+func (s *memorySink) IncEnforce(labelValues []string) {
+	s.count("enforce", labelValues, 1)
+	if s.next != nil {
+		s.next.IncEnforce(labelValues)
+	}
+}
+
+func (s *memorySink) AddEnforce(labelValues []string, count float64) {
+	s.count("enforce", labelValues, count)
+	if s.next != nil {
+		s.next.AddEnforce(labelValues, count)
+	}
+}
+
+func (s *memorySink) ObserveEnforceDuration(labelValues []string, duration float64) {
+	s.observe("enforce", labelValues, duration)
+	if s.next != nil {
+		s.next.ObserveEnforceDuration(labelValues, duration)
+	}
+}
+
+func (s *memorySink) IncPolicyOp(labelValues []string) {
+	s.count("policy", labelValues, 1)
+	if s.next != nil {
+		s.next.IncPolicyOp(labelValues)
+	}
+}
+
+func (s *memorySink) ObservePolicyOpDuration(labelValues []string, duration float64) {
+	s.observe("policy", labelValues, duration)
+	if s.next != nil {
+		s.next.ObservePolicyOpDuration(labelValues, duration)
+	}
+}
+
+func TestMetricSink(t *testing.T) {
+	sink := newMemorySink(nil)
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{MetricSink: sink, PolicyAdapterLabel: true})
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", Allowed: true, StartTime: time.Now()})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", Allowed: true, StartTime: time.Now()})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Allowed: false, StartTime: time.Now()})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventAddPolicy, Adapter: "file", StartTime: time.Now()})
+	logger.SingleShotEnforce("alice", "data1", "read", "domain2", true)
+	logger.AddEnforceCounts("domain2", 5, 3)
+
+	for key, expected := range map[string]float64{
+		"enforce{true,domain1}":       2,
+		"enforce{false,default}":      1,
+		"enforce{true,domain2}":       6,
+		"enforce{false,domain2}":      3,
+		"policy{addPolicy,true,file}": 1,
+	} {
+		if got := sink.counts[key]; got != expected {
+			t.Errorf("Expected count %v for %s, got %v", expected, key, got)
+		}
+	}
+	for key, expected := range map[string]int{
+		"enforce{true,domain1}":  2,
+		"enforce{false,default}": 1,
+		"policy{addPolicy,file}": 1,
+	} {
+		got := sink.durations[key]
+		if len(got) != expected || slices.ContainsFunc(got, func(d float64) bool { return d < 0 || d > 1 }) {
+			t.Errorf("Expected %d durations between 0 and 1s for %s, got %v", expected, key, got)
+		}
+	}
+
+	// The sink replaces the Prometheus metrics.
+	if count := testutil.CollectAndCount(logger.GetEnforceTotal()); count != 0 {
+		t.Errorf("Expected no enforce series, got %d", count)
+	}
+	if count := testutil.CollectAndCount(logger.GetPolicyOpsTotal()); count != 0 {
+		t.Errorf("Expected no policy operation series, got %d", count)
+	}
+}
+
+func TestMetricSink_ForwardToPrometheus(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, nil)
+	defer logger.UnregisterFrom(registry)
+	sink := newMemorySink(logger.PrometheusSink())
+	if err := logger.Reconfigure(&PrometheusLoggerOptions{MetricSink: sink}); err != nil {
+		t.Fatalf("Reconfigure failed: %v", err)
+	}
+
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Domain: "domain1", Allowed: true})
+	logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventRemovePolicy})
+
+	if got := sink.counts["enforce{true,domain1}"]; got != 1 {
+		t.Errorf("Expected 1 enforce request in the sink, got %v", got)
+	}
+	if got := testutil.ToFloat64(logger.GetEnforceTotal().WithLabelValues("true", "domain1")); got != 1 {
+		t.Errorf("Expected 1 enforce request in Prometheus, got %v", got)
+	}
+	if got := sink.counts["policy{removePolicy,true}"]; got != 1 {
+		t.Errorf("Expected 1 policy operation in the sink, got %v", got)
+	}
+	if got := testutil.ToFloat64(logger.GetPolicyOpsTotal().WithLabelValues("removePolicy", "true")); got != 1 {
+		t.Errorf("Expected 1 policy operation in Prometheus, got %v", got)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutil provides helpers for testing code that records casbin
+// events with a PrometheusLogger, such as waiting for events recorded on
+// other goroutines.
+package testutil
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	prometheuslogger "github.com/casbin/casbin-prometheus-logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// pollInterval is how often the wait helpers gather the metrics.
+const pollInterval = 10 * time.Millisecond
+
+// WaitForEnforceCount waits until the enforce requests counted in
+// casbin_enforce_total of registry, summed over all series, equal expected,
+// and returns an error if they do not within timeout. It is meant for tests
+// asserting metrics recorded asynchronously, such as by a callback running
+// on another goroutine. Only the default metric name is looked up; use
+// WaitForLoggerEnforceCount for loggers configured with a Namespace,
+// MetricNames, StrictNaming or another renaming option.
+func WaitForEnforceCount(registry prometheus.Gatherer, expected int, timeout time.Duration) error {
+	return waitForEnforceCount(registry, "casbin_enforce_total", expected, timeout)
+}
+
+// WaitForLoggerEnforceCount is like WaitForEnforceCount, but reads the enforce
+// counter of logger directly, so it works whatever the counter is named. It
+// returns an error if the logger has no enforce counter, for example because
+// EnabledMetrics leaves it out.
+func WaitForLoggerEnforceCount(logger *prometheuslogger.PrometheusLogger, expected int, timeout time.Duration) error {
+	counter := logger.GetEnforceTotal()
+	if counter == nil {
+		return errors.New("the logger has no enforce counter")
+	}
+	// A private registry gathers only the counter, under whatever name.
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(counter); err != nil {
+		return fmt.Errorf("registering the enforce counter: %w", err)
+	}
+	return waitForEnforceCount(registry, "", expected, timeout)
+}
+
+// waitForEnforceCount polls the enforce count of registry until it equals
+// expected or timeout expires.
+func waitForEnforceCount(registry prometheus.Gatherer, name string, expected int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		count, err := enforceCount(registry, name)
+		if err != nil {
+			return err
+		}
+		if count == float64(expected) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %v waiting for %d enforce requests, got %v", timeout, expected, count)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// enforceCount returns the sum of the counter named name in registry, or of
+// every counter if name is empty.
+func enforceCount(registry prometheus.Gatherer, name string) (float64, error) {
+	families, err := registry.Gather()
+	if err != nil {
+		return 0, fmt.Errorf("gathering metrics: %w", err)
+	}
+	var count float64
+	for _, family := range families {
+		if name != "" && family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			count += metric.GetCounter().GetValue()
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	prometheuslogger "github.com/casbin/casbin-prometheus-logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestWaitForEnforceCount(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := prometheuslogger.NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			time.Sleep(5 * time.Millisecond)
+			logger.OnAfterEvent(&prometheuslogger.LogEntry{
+				IsActive:  true,
+				EventType: prometheuslogger.EventEnforce,
+				StartTime: time.Now(),
+				Domain:    []string{"domain1", "domain2"}[i%2],
+				Allowed:   i%3 == 0,
+			})
+		}
+	}()
+
+	if err := WaitForEnforceCount(registry, 5, 5*time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitForEnforceCount_Timeout(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := prometheuslogger.NewPrometheusLoggerWithRegistry(registry)
+	defer logger.UnregisterFrom(registry)
+
+	logger.OnAfterEvent(&prometheuslogger.LogEntry{IsActive: true, EventType: prometheuslogger.EventEnforce, StartTime: time.Now()})
+
+	start := time.Now()
+	if err := WaitForEnforceCount(registry, 2, 50*time.Millisecond); err == nil {
+		t.Error("Expected an error when the count is not reached")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected WaitForEnforceCount to give up after the timeout, took %v", elapsed)
+	}
+}
+
+func TestWaitForLoggerEnforceCount(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := prometheuslogger.NewPrometheusLoggerWithOptions(registry, &prometheuslogger.PrometheusLoggerOptions{
+		Namespace: "authz",
+	})
+	defer logger.UnregisterFrom(registry)
+
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			logger.OnAfterEvent(&prometheuslogger.LogEntry{
+				IsActive:  true,
+				EventType: prometheuslogger.EventEnforce,
+				StartTime: time.Now(),
+				Allowed:   true,
+			})
+		}
+	}()
+
+	if err := WaitForLoggerEnforceCount(logger, 3, 5*time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWaitForLoggerEnforceCount_NoCounter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := prometheuslogger.NewPrometheusLoggerWithOptions(registry, &prometheuslogger.PrometheusLoggerOptions{
+		EnabledMetrics: []string{"policy_operations_total"},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	if err := WaitForLoggerEnforceCount(logger, 1, time.Millisecond); err == nil {
+		t.Error("Expected an error for a logger without an enforce counter")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LabelCount is the number of enforce requests recorded with a label value.
+type LabelCount struct {
+	Value string
+	Count float64
+}
+
+// TopLabelValues returns the n values of the enforce label that were recorded
+// with the most enforce requests, most frequent first, to find the values
+// behind a growth in series. Values with equal counts are ordered by value.
+// A non-positive n returns every value. It returns nil if label is not an
+// enforce label.
+func (p *PrometheusLogger) TopLabelValues(label string, n int) []LabelCount {
+	if p.enforceTotal == nil || !slices.Contains(p.enforceLabels, label) {
+		return nil
+	}
+	families, err := gatherCollectors(p.enforceTotal)
+	if err != nil {
+		return nil
+	}
+
+	counts := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			counts[labelMap(metric.GetLabel())[label]] += metric.GetCounter().GetValue()
+		}
+	}
+
+	top := make([]LabelCount, 0, len(counts))
+	for value, count := range counts {
+		top = append(top, LabelCount{Value: value, Count: count})
+	}
+	slices.SortFunc(top, func(a, b LabelCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	if n > 0 && len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// EnforceSeriesLabels returns the label sets of the series of
+// casbin_enforce_total that currently exist, to find the label combinations
+// behind a growth in cardinality.
+func (p *PrometheusLogger) EnforceSeriesLabels() []prometheus.Labels {
+	if p.enforceTotal == nil {
+		return nil
+	}
+	families, err := gatherCollectors(p.enforceTotal)
+	if err != nil {
+		return nil
+	}
+
+	var series []prometheus.Labels
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			series = append(series, labelMap(metric.GetLabel()))
+		}
+	}
+	return series
+}
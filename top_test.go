@@ -0,0 +1,97 @@
+// Copyright 2026 The casbin Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheuslogger
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTopLabelValues(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelAllowed, LabelSubject},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for subject, count := range map[string]int{"alice": 5, "bob": 3, "carol": 3, "dave": 1} {
+		for i := 0; i < count; i++ {
+			// Split each subject across both allowed series.
+			logger.OnAfterEvent(&LogEntry{IsActive: true, EventType: EventEnforce, Subject: subject, Allowed: i%2 == 0, StartTime: time.Now()})
+		}
+	}
+
+	expected := []LabelCount{{"alice", 5}, {"bob", 3}, {"carol", 3}}
+	if top := logger.TopLabelValues(LabelSubject, 3); !slices.Equal(top, expected) {
+		t.Errorf("Expected %v, got %v", expected, top)
+	}
+	if top := logger.TopLabelValues(LabelSubject, 0); len(top) != 4 {
+		t.Errorf("Expected every value for n = 0, got %v", top)
+	}
+	if top := logger.TopLabelValues(LabelDomain, 3); top != nil {
+		t.Errorf("Expected nil for a label that is not recorded, got %v", top)
+	}
+
+	recorder := httptest.NewRecorder()
+	logger.TopLabelValuesHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/top?label=subject&n=2", nil))
+	if body := recorder.Body.String(); body != "alice 5\nbob 3\n" {
+		t.Errorf("Unexpected handler output %q", body)
+	}
+
+	recorder = httptest.NewRecorder()
+	logger.TopLabelValuesHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/top?label=tenant", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown label, got %d", recorder.Code)
+	}
+}
+
+func TestEnforceSeriesLabels(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := NewPrometheusLoggerWithOptions(registry, &PrometheusLoggerOptions{
+		EnforceLabels: []string{LabelSubject, LabelObject},
+	})
+	defer logger.UnregisterFrom(registry)
+
+	for _, entry := range []*LogEntry{
+		{Subject: "alice", Object: "data1"},
+		{Subject: "alice", Object: "data2"},
+		{Subject: "bob", Object: "data1"},
+		{Subject: "alice", Object: "data1"},
+	} {
+		entry.IsActive, entry.EventType, entry.StartTime = true, EventEnforce, time.Now()
+		logger.OnAfterEvent(entry)
+	}
+
+	expected := []prometheus.Labels{
+		{"subject": "alice", "object": "data1"},
+		{"subject": "alice", "object": "data2"},
+		{"subject": "bob", "object": "data1"},
+	}
+	series := logger.EnforceSeriesLabels()
+	if len(series) != len(expected) {
+		t.Fatalf("Expected %d series, got %v", len(expected), series)
+	}
+	for _, labels := range expected {
+		if !slices.ContainsFunc(series, func(s prometheus.Labels) bool { return maps.Equal(s, labels) }) {
+			t.Errorf("Expected series %v in %v", labels, series)
+		}
+	}
+}
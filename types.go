@@ -14,7 +14,14 @@
 
 package prometheuslogger
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
 
 // EventType represents the type of logging event.
 // These types are defined to match the casbin/v2/log package interface.
@@ -22,13 +29,88 @@ type EventType string
 
 // Event type constants.
 const (
-	EventEnforce      EventType = "enforce"
-	EventAddPolicy    EventType = "addPolicy"
-	EventRemovePolicy EventType = "removePolicy"
-	EventLoadPolicy   EventType = "loadPolicy"
-	EventSavePolicy   EventType = "savePolicy"
+	EventEnforce              EventType = "enforce"
+	EventEnforceEx            EventType = "enforceEx"
+	EventAddPolicy            EventType = "addPolicy"
+	EventRemovePolicy         EventType = "removePolicy"
+	EventLoadPolicy           EventType = "loadPolicy"
+	EventSavePolicy           EventType = "savePolicy"
+	EventAddPolicies          EventType = "addPolicies"
+	EventRemovePolicies       EventType = "removePolicies"
+	EventRemoveFilteredPolicy EventType = "removeFilteredPolicy"
+	EventUpdatePolicy         EventType = "updatePolicy"
+	EventUpdatePolicies       EventType = "updatePolicies"
+)
+
+// builtinEventTypes are the event types defined by casbin.
+var builtinEventTypes = []EventType{
+	EventEnforce,
+	EventEnforceEx,
+	EventAddPolicy,
+	EventRemovePolicy,
+	EventLoadPolicy,
+	EventSavePolicy,
+	EventAddPolicies,
+	EventRemovePolicies,
+	EventRemoveFilteredPolicy,
+	EventUpdatePolicy,
+	EventUpdatePolicies,
+}
+
+// customEventTypes holds the event types added with RegisterEventType.
+var (
+	customEventTypesMu sync.RWMutex
+	customEventTypes   = make(map[EventType]bool)
 )
 
+// RegisterEventType registers an event type defined outside casbin, such as a
+// custom enforcement phase, and returns it. Events of a registered type are
+// recorded in the policy operation metrics with the type as the operation,
+// instead of being counted as unknown. Registering a built-in or already
+// registered type has no effect. It panics if name is empty.
+func RegisterEventType(name string) EventType {
+	if name == "" {
+		panic("prometheuslogger: empty event type name")
+	}
+	eventType := EventType(name)
+	if slices.Contains(builtinEventTypes, eventType) {
+		return eventType
+	}
+
+	customEventTypesMu.Lock()
+	defer customEventTypesMu.Unlock()
+	customEventTypes[eventType] = true
+	return eventType
+}
+
+// isCustomEventType reports whether eventType was added with RegisterEventType.
+func isCustomEventType(eventType EventType) bool {
+	customEventTypesMu.RLock()
+	defer customEventTypesMu.RUnlock()
+	return customEventTypes[eventType]
+}
+
+// knownEventTypes returns the built-in and registered event types.
+func knownEventTypes() []EventType {
+	customEventTypesMu.RLock()
+	defer customEventTypesMu.RUnlock()
+	return append(slices.Clone(builtinEventTypes), slices.Collect(maps.Keys(customEventTypes))...)
+}
+
+// ParseEventType returns the built-in or registered event type named name.
+func ParseEventType(name string) (EventType, error) {
+	eventType := EventType(name)
+	if !slices.Contains(builtinEventTypes, eventType) && !isCustomEventType(eventType) {
+		return "", fmt.Errorf("unknown event type %q", name)
+	}
+	return eventType, nil
+}
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	return string(t)
+}
+
 // LogEntry represents a complete log entry for a Casbin event.
 // This type is defined to match the casbin/v2/log package interface.
 type LogEntry struct {
@@ -39,6 +121,16 @@ type LogEntry struct {
 	StartTime time.Time
 	EndTime   time.Time
 	Duration  time.Duration
+	// WaitStart is when an enforce request started waiting in a queue in
+	// front of the enforcer. Defaults to StartTime.
+	WaitStart time.Time
+	// EvalStart is when the enforcer started evaluating a request after
+	// waiting. If set, wait and evaluation durations are recorded
+	// separately.
+	EvalStart time.Time
+	// CPUDuration is the CPU time spent on an enforce request, measured by
+	// the caller. Zero means unknown.
+	CPUDuration time.Duration
 
 	// Enforce parameters.
 	// Subject is the user or entity requesting access.
@@ -51,14 +143,116 @@ type LogEntry struct {
 	Domain string
 	// Allowed indicates whether the enforcement request was allowed.
 	Allowed bool
+	// ExplicitDeny indicates that a denied request matched a deny rule,
+	// rather than being denied because no rule matched.
+	ExplicitDeny bool
+	// DenyCause is why a denied request was denied: DenyCauseNoRole,
+	// DenyCauseRoleDenied or DenyCauseNoPolicy. Empty means unknown.
+	// DenyCauseOf computes it from the explanation of EnforceEx.
+	DenyCause string
+	// Weight is the cost of the enforcement request for quota accounting.
+	// Zero is treated as 1 and negative weights are not recorded.
+	Weight float64
+	// RulesEvaluated is the number of policy rules the matcher iterated
+	// over. Zero means unknown.
+	RulesEvaluated int
+	// Attributes are attributes of an ABAC request, such as "department".
+	// The keys listed in AttributeLabels become enforce labels.
+	Attributes map[string]string
+	// ArgCount is the number of request arguments passed to the enforcer.
+	// Zero means unknown.
+	ArgCount int
+	// Retries is the number of times the enforcement request was retried,
+	// for example while the policy was being reloaded.
+	Retries int
+	// CacheHit reports whether the decision was served from the decision
+	// cache of a cached enforcer. Nil means the enforcer has no cache.
+	CacheHit *bool
 
 	// Rules contains the policy rules involved in the operation.
 	Rules [][]string
 	// RuleCount is the number of rules affected by the operation.
 	RuleCount int
+	// OldRuleCount and NewRuleCount are the number of rules replaced and
+	// added by an update operation.
+	OldRuleCount int
+	NewRuleCount int
+	// Ptype is the policy type affected by a policy operation, such as "p" or "g".
+	Ptype string
+	// Adapter is the type of the adapter that performed a policy operation,
+	// such as "file" or "mysql".
+	Adapter string
+	// Source is where a policy operation was triggered from, such as
+	// "file", "db" or "watcher", when the policy is loaded from several
+	// sources.
+	Source string
 
 	// Error contains any error that occurred during the event.
 	Error error
+	// ErrorCategory classifies Error for casbin_policy_operation_errors_total,
+	// such as "db_down", "conflict" or "serialization".
+	ErrorCategory string
+
+	// concurrencyDomain is the domain label value the entry was counted as
+	// in flight for by OnBeforeEvent, or empty.
+	concurrencyDomain string
+}
+
+// ErrInvalidEntry is wrapped by the errors returned from LogEntry.Validate.
+var ErrInvalidEntry = errors.New("invalid log entry")
+
+// Validate checks that the entry can be recorded with the given enforce
+// labels. Enforce entries must set the subject, object and action when the
+// matching label is enabled, and counts must not be negative.
+func (e *LogEntry) Validate(labels []string) error {
+	switch e.EventType {
+	case EventEnforce, EventEnforceEx:
+		for _, label := range labels {
+			var value string
+			switch label {
+			case LabelSubject:
+				value = e.Subject
+			case LabelObject:
+				value = e.Object
+			case LabelAction:
+				value = e.Action
+			default:
+				continue
+			}
+			if value == "" {
+				return fmt.Errorf("%w: enforce entry has an empty %s", ErrInvalidEntry, label)
+			}
+		}
+		if e.RulesEvaluated < 0 {
+			return fmt.Errorf("%w: enforce entry has negative RulesEvaluated %d", ErrInvalidEntry, e.RulesEvaluated)
+		}
+		if e.CPUDuration < 0 {
+			return fmt.Errorf("%w: enforce entry has negative CPUDuration %v", ErrInvalidEntry, e.CPUDuration)
+		}
+		if e.ArgCount < 0 {
+			return fmt.Errorf("%w: enforce entry has negative ArgCount %d", ErrInvalidEntry, e.ArgCount)
+		}
+		if e.Retries < 0 {
+			return fmt.Errorf("%w: enforce entry has negative Retries %d", ErrInvalidEntry, e.Retries)
+		}
+	case EventAddPolicy, EventRemovePolicy, EventLoadPolicy, EventSavePolicy,
+		EventAddPolicies, EventRemovePolicies, EventRemoveFilteredPolicy, EventUpdatePolicy, EventUpdatePolicies:
+		if e.RuleCount < 0 {
+			return fmt.Errorf("%w: %s entry has negative RuleCount %d", ErrInvalidEntry, e.EventType, e.RuleCount)
+		}
+		if e.OldRuleCount < 0 || e.NewRuleCount < 0 {
+			return fmt.Errorf("%w: %s entry has negative OldRuleCount %d or NewRuleCount %d", ErrInvalidEntry, e.EventType, e.OldRuleCount, e.NewRuleCount)
+		}
+	}
+	return nil
+}
+
+// EnforceResult is the decision for one object of a multi-object enforce request.
+type EnforceResult struct {
+	// Object is the resource the decision applies to.
+	Object string
+	// Allowed indicates whether access to Object was allowed.
+	Allowed bool
 }
 
 // Logger defines the interface for event-driven logging in Casbin.